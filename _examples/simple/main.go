@@ -10,15 +10,22 @@ import (
 )
 
 var (
-	port = flag.Int("port", 9000, "Listen port")
+	config = flag.String("config", "", "Config file, SREST_* environment variables override it")
 )
 
 func main() {
 	flag.Parse()
 	runtime.GOMAXPROCS(runtime.NumCPU())
-	m := srest.New(nil)
+	o, err := srest.LoadOptions(*config)
+	if err != nil {
+		log.Fatalf("load options : err [%s]", err)
+	}
+	if o.Addr == "" {
+		o.Addr = ":9000"
+	}
+	m := srest.New(o)
 	m.Get("/", http.HandlerFunc(homeHandler))
-	<-m.Run(*port)
+	<-m.Run(0)
 	log.Println("Done")
 }
 
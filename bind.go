@@ -1,35 +1,286 @@
 package srest
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
 	"net/url"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/gorilla/schema"
 )
 
+const (
+	// DefaultJSONBodyLimit is used when BinderOptions.JSONBodyLimit is zero.
+	DefaultJSONBodyLimit = 1 << 20
+
+	// DefaultBodyLimit is used when BinderOptions.BodyLimit is zero.
+	DefaultBodyLimit = 10 << 20
+
+	// DefaultMultipartMemory is used when BinderOptions.MultipartMemory is
+	// zero.
+	DefaultMultipartMemory = 32 << 20
+)
+
 // Modeler interface
 type Modeler interface {
 	IsValid() error
 }
 
-var (
-	// schDecoder default gorilla schema decoder.
-	schDecoder = schema.NewDecoder()
+// ContextModeler interface is preferred over Modeler by Bind functions. ctx
+// is the request context, Bind uses context.Background, with the
+// dependencies of the Binder, see Binder.Provide.
+type ContextModeler interface {
+	ValidContext(ctx context.Context) error
+}
 
+var (
 	// ErrImplementsModeler error returned when modeler interface is not implemented.
 	ErrImplementsModeler = errors.New("srest: modeler interface not found")
+
+	// ErrUnsupportedMediaType error returned when the request Content-Type
+	// can't be decoded.
+	ErrUnsupportedMediaType = errors.New("srest: unsupported media type")
+
+	// ErrBodyTooLarge error returned when the request body exceeds the limit.
+	ErrBodyTooLarge = errors.New("srest: request body too large")
+
+	// ErrEmptyBody error returned when the request body is empty.
+	ErrEmptyBody = errors.New("srest: empty request body")
 )
 
-// Bind implements gorilla schema and runs IsValid method from data.
+// JSONError type is a malformed JSON body. Offset is the byte where the
+// decoder failed and Field the destination field when the value type is
+// wrong.
+type JSONError struct {
+	Offset int64
+	Field  string
+	Err    error
+}
+
+// Error implements error interface.
+func (e *JSONError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("srest: invalid JSON at offset %d: field %s: %s", e.Offset, e.Field, e.Err)
+	}
+	return fmt.Sprintf("srest: invalid JSON at offset %d: %s", e.Offset, e.Err)
+}
+
+// Unwrap returns the decoder error.
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+// RequestError type is a request body or value that can't be parsed, e.g.
+// malformed XML or multipart bodies. Malformed JSON is a JSONError.
+type RequestError struct {
+	Err error
+}
+
+// Error implements error interface.
+func (e *RequestError) Error() string {
+	return "srest: malformed request: " + e.Err.Error()
+}
+
+// Unwrap returns the parser error.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Bind implements gorilla schema, sets `default` and `mod` tags, checks
+// `validate` tags and runs IsValid method from data. See Modify and Validate.
+// Fields that can't be decoded and fields that fail validation are returned
+// together as ValidationErrors. It uses DefaultBinder.
 func Bind(vars url.Values, dst interface{}) error {
-	err := schDecoder.Decode(dst, vars)
-	if err != nil {
+	return DefaultBinder.Bind(vars, dst)
+}
+
+// BindContext is like Bind with ctx for ContextModeler, messages are
+// translated to the locale of WithLocale.
+func BindContext(ctx context.Context, vars url.Values, dst interface{}) error {
+	return DefaultBinder.BindContext(ctx, vars, dst)
+}
+
+// BindJSON is like Binder.BindJSON with DefaultBinder.
+func BindJSON(r *http.Request, dst interface{}) error {
+	return DefaultBinder.BindJSON(r, dst)
+}
+
+// BindRequest is like Binder.BindRequest with DefaultBinder.
+func BindRequest(r *http.Request, dst interface{}) error {
+	return DefaultBinder.BindRequest(r, dst)
+}
+
+// isValid runs Modify, Validate and then ValidContext or IsValid method from
+// dst, the latter only when there are no errors. errs are the fields that
+// failed decoding. Models with `validate` tags don't need to implement
+// Modeler.
+func isValid(ctx context.Context, dst interface{}, errs ValidationErrors, tag string) error {
+	if err := modify(dst, tag); err != nil {
 		return err
 	}
-	// check model is valid
+	if err := validate(dst, errs, tag); err != nil {
+		verrs, ok := err.(ValidationErrors)
+		if !ok {
+			return err
+		}
+		errs = append(errs, verrs...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	if cm, ok := dst.(ContextModeler); ok {
+		return cm.ValidContext(ctx)
+	}
 	mo, ok := dst.(Modeler)
 	if !ok {
+		if hasRules(reflect.TypeOf(dst), map[reflect.Type]bool{}) {
+			return nil
+		}
 		return ErrImplementsModeler
 	}
 	return mo.IsValid()
 }
+
+// fieldErrors adds to errs the fields that failed decoding, other errors
+// are returned.
+func fieldErrors(errs *ValidationErrors, err error) error {
+	var jsonErr *JSONError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &jsonErr) && jsonErr.Field != "":
+		*errs = append(*errs, NewFieldError(jsonErr.Field, "type", ""))
+		return nil
+	}
+	multi, ok := err.(schema.MultiError)
+	if !ok {
+		return err
+	}
+	keys := make([]string, 0, len(multi))
+	for k := range multi {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var res ValidationErrors
+	for _, k := range keys {
+		switch x := multi[k].(type) {
+		case schema.ConversionError:
+			res = append(res, NewFieldError(x.Key, "type", ""))
+		case schema.EmptyFieldError:
+			res = append(res, NewFieldError(x.Key, "required", ""))
+		case schema.UnknownKeyError:
+			res = append(res, NewFieldError(x.Key, "unknown", ""))
+		default:
+			// schema has no type for indexes over its max size.
+			if strings.Contains(x.Error(), "maxSize") {
+				return &RequestError{Err: x}
+			}
+			return err
+		}
+	}
+	*errs = append(*errs, res...)
+	return nil
+}
+
+// splitQuery separates query values from path params, the latter are added
+// to the query with ":" prefix, see registerVars.
+func splitQuery(v url.Values) (url.Values, url.Values) {
+	query, params := url.Values{}, url.Values{}
+	for k := range v {
+		if strings.HasPrefix(k, ":") {
+			params[k[1:]] = v[k]
+			continue
+		}
+		query[k] = v[k]
+	}
+	return query, params
+}
+
+// limitBody limits rc to n bytes when n is greater than zero.
+func limitBody(rc io.ReadCloser, n int64) io.ReadCloser {
+	if n <= 0 {
+		return rc
+	}
+	return http.MaxBytesReader(nil, rc, n)
+}
+
+// bodyError returns ErrBodyTooLarge when the body limit was reached.
+func bodyError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return ErrBodyTooLarge
+	}
+	return err
+}
+
+// parseError returns the body errors of a parser as RequestError.
+func parseError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err = bodyError(err); err == ErrBodyTooLarge {
+		return err
+	}
+	return &RequestError{Err: err}
+}
+
+// clientError reports if err is caused by the request.
+func clientError(err error) bool {
+	var jsonErr *JSONError
+	var reqErr *RequestError
+	return err == ErrEmptyBody || errors.As(err, &jsonErr) || errors.As(err, &reqErr)
+}
+
+// isJSON reports if the media type ct is JSON.
+func isJSON(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" ||
+		strings.HasPrefix(mt, "application/") && strings.HasSuffix(mt, "+json")
+}
+
+// jsonError converts decoder errors to the package errors.
+func jsonError(dec *json.Decoder, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var dstErr *json.InvalidUnmarshalError
+	switch {
+	case bodyError(err) == ErrBodyTooLarge:
+		return ErrBodyTooLarge
+	case err == io.EOF:
+		return ErrEmptyBody
+	case errors.As(err, &syntaxErr):
+		return &JSONError{Offset: syntaxErr.Offset, Err: err}
+	case errors.As(err, &typeErr):
+		return &JSONError{Offset: typeErr.Offset, Field: typeErr.Field, Err: fmt.Errorf("cannot use %s as %s", typeErr.Value, typeErr.Type)}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no type for unknown fields.
+		return &JSONError{Offset: dec.InputOffset(), Err: errors.New(strings.TrimPrefix(err.Error(), "json: "))}
+	case errors.As(err, &dstErr):
+		return err
+	}
+	// Read errors and errors of UnmarshalJSON methods.
+	return &JSONError{Offset: dec.InputOffset(), Err: err}
+}
+
+// countReader counts the bytes read, the decoder doesn't report the offset
+// of truncated bodies.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
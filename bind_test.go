@@ -1,28 +1,32 @@
 package srest
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"mime/multipart"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-// Model struct satisfies Modeler interface
-type Model struct {
+// NameModel struct satisfies Modeler interface
+type NameModel struct {
 	Name string `schema:"name"`
 }
 
 // IsValid modeler interface
-func (m *Model) IsValid() error {
+func (m *NameModel) IsValid() error {
 	return nil
 }
 
 func TestBind(t *testing.T) {
 	v := url.Values{}
 	v.Add("name", "x")
-	var x Model
+	var x NameModel
 	err := Bind(v, &x)
 	assert.Nil(t, err)
 }
@@ -61,3 +65,197 @@ func TestBindDecoderFail(t *testing.T) {
 	err := Bind(v, x)
 	assert.EqualValues(t, "schema: interface must be a pointer to struct", fmt.Sprintf("%s", err))
 }
+
+// JSONModel struct satisfies Modeler interface
+type JSONModel struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// IsValid modeler interface
+func (m *JSONModel) IsValid() error {
+	if m.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func TestBindJSON(t *testing.T) {
+	table := []struct {
+		Purpose       string
+		ContentType   string
+		Body          string
+		Limit         int64
+		UnknownFields bool
+		Exp           JSONModel
+		ExpErr        string
+	}{
+		{"1. OK", "application/json", `{"name":"x","age":3}`, DefaultJSONBodyLimit, false, JSONModel{"x", 3}, ""},
+		{"2. OK: charset and unknown field", "application/json; charset=UTF-8", `{"name":"x","other":1}`, DefaultJSONBodyLimit, false, JSONModel{Name: "x"}, ""},
+		{"3. OK: suffix media type without limit", "application/merge-patch+json", `{"name":"x"}`, -1, false, JSONModel{Name: "x"}, ""},
+		{"4. Fail: content type", "text/plain", `{"name":"x"}`, DefaultJSONBodyLimit, false, JSONModel{}, "srest: unsupported media type"},
+		{"5. Fail: empty content type", "", `{"name":"x"}`, DefaultJSONBodyLimit, false, JSONModel{}, "srest: unsupported media type"},
+		{"6. Fail: body too large", "application/json", `{"name":"xxxxxxxxxx"}`, 10, false, JSONModel{}, "srest: request body too large"},
+		{"7. Fail: empty body", "application/json", ``, DefaultJSONBodyLimit, false, JSONModel{}, "srest: empty request body"},
+		{"8. Fail: syntax", "application/json", `{"name":"x",}`, DefaultJSONBodyLimit, false, JSONModel{}, "srest: invalid JSON at offset 13: invalid character '}' looking for beginning of object key string"},
+		{"9. Fail: truncated", "application/json", `{"name":"x"`, DefaultJSONBodyLimit, false, JSONModel{}, "srest: invalid JSON at offset 11: unexpected EOF"},
+		{"10. Fail: field type", "application/json", `{"name":"x","age":"3"}`, DefaultJSONBodyLimit, false, JSONModel{Name: "x"}, "srest: validation failed: age has an invalid value"},
+		{"11. Fail: unknown field", "application/json", `{"name":"x","other":1}`, DefaultJSONBodyLimit, true, JSONModel{Name: "x"}, "srest: invalid JSON at offset 22: unknown field \"other\""},
+		{"12. Fail: trailing data", "application/json", `{"name":"x"} {}`, DefaultJSONBodyLimit, false, JSONModel{Name: "x"}, "srest: invalid JSON at offset 13: unexpected data after value"},
+		{"13. Fail: IsValid", "application/json", `{"age":3}`, DefaultJSONBodyLimit, false, JSONModel{Age: 3}, "name is required"},
+	}
+	for _, x := range table {
+		b := NewBinder(&BinderOptions{JSONBodyLimit: x.Limit, DisallowUnknownFields: x.UnknownFields})
+		r := httptest.NewRequest("POST", "/", strings.NewReader(x.Body))
+		if x.ContentType != "" {
+			r.Header.Set("Content-Type", x.ContentType)
+		}
+		var actual JSONModel
+		err := b.BindJSON(r, &actual)
+		if x.ExpErr == "" {
+			assert.Nil(t, err, x.Purpose)
+		} else {
+			assert.EqualValues(t, x.ExpErr, fmt.Sprintf("%s", err), x.Purpose)
+		}
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+	}
+}
+
+func TestBindJSONModelerFail(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"x"}`))
+	r.Header.Set("Content-Type", "application/json")
+	var x struct {
+		Name string `json:"name"`
+	}
+	err := BindJSON(r, &x)
+	assert.EqualValues(t, ErrImplementsModeler, err)
+}
+
+// RequestModel struct satisfies Modeler interface
+type RequestModel struct {
+	ID    string   `schema:"id" json:"id" xml:"id"`
+	Name  string   `schema:"name" json:"name" xml:"name"`
+	Page  int      `schema:"page" json:"page" xml:"page"`
+	Tags  []string `schema:"tags" json:"tags" xml:"tags"`
+	valid bool
+}
+
+// IsValid modeler interface
+func (m *RequestModel) IsValid() error {
+	m.valid = true
+	return nil
+}
+
+// multipartBody returns a multipart body with fields and its Content-Type.
+func multipartBody(fields map[string]string) (string, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			return "", "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+	return buf.String(), w.FormDataContentType(), nil
+}
+
+func TestBindRequest(t *testing.T) {
+	mp, mpType, err := multipartBody(map[string]string{"name": "multipart", "id": "body"})
+	assert.Nil(t, err)
+
+	table := []struct {
+		Purpose     string
+		Query       string
+		Params      map[string]string
+		ContentType string
+		Body        string
+		Limit       int64
+		Exp         RequestModel
+		ExpErr      string
+	}{
+		{
+			"1. OK: query and path params without body",
+			"name=query&page=2&tags=a&tags=b&other=x", map[string]string{"id": "1"},
+			"", "", DefaultBodyLimit,
+			RequestModel{"1", "query", 2, []string{"a", "b"}, true}, "",
+		},
+		{
+			"2. OK: form body over query, path params over body",
+			"name=query&page=2", map[string]string{"id": "1"},
+			"application/x-www-form-urlencoded", "name=form&id=2", DefaultBodyLimit,
+			RequestModel{"1", "form", 2, nil, true}, "",
+		},
+		{
+			"3. OK: JSON",
+			"page=2", nil,
+			"application/json", `{"name":"json","tags":["a"],"other":1}`, DefaultBodyLimit,
+			RequestModel{"", "json", 2, []string{"a"}, true}, "",
+		},
+		{
+			"4. OK: XML",
+			"", map[string]string{"id": "1"},
+			"application/xml; charset=UTF-8", `<m><name>xml</name><page>3</page><id>2</id></m>`, DefaultBodyLimit,
+			RequestModel{"1", "xml", 3, nil, true}, "",
+		},
+		{
+			"5. OK: multipart",
+			"", map[string]string{"id": "1"},
+			mpType, mp, DefaultBodyLimit,
+			RequestModel{"1", "multipart", 0, nil, true}, "",
+		},
+		{
+			"6. Fail: unsupported media type",
+			"", nil,
+			"text/plain", "name=x", DefaultBodyLimit,
+			RequestModel{}, "srest: unsupported media type",
+		},
+		{
+			"7. Fail: query value",
+			"page=x", nil,
+			"", "", DefaultBodyLimit,
+			RequestModel{}, "srest: validation failed: page has an invalid value",
+		},
+		{
+			"8. Fail: form too large",
+			"", nil,
+			"application/x-www-form-urlencoded", "name=form", 4,
+			RequestModel{}, "srest: request body too large",
+		},
+		{
+			"9. Fail: multipart too large",
+			"", nil,
+			mpType, mp, 4,
+			RequestModel{}, "srest: request body too large",
+		},
+		{
+			"10. Fail: empty XML",
+			"", nil,
+			"text/xml", "", DefaultBodyLimit,
+			RequestModel{}, "srest: empty request body",
+		},
+		{
+			"11. Fail: JSON syntax",
+			"", nil,
+			"application/json", `{`, DefaultBodyLimit,
+			RequestModel{}, "srest: invalid JSON at offset 1: unexpected EOF",
+		},
+	}
+	for _, x := range table {
+		b := NewBinder(&BinderOptions{BodyLimit: x.Limit})
+		r := httptest.NewRequest("POST", "/?"+x.Query, strings.NewReader(x.Body))
+		if x.ContentType != "" {
+			r.Header.Set("Content-Type", x.ContentType)
+		}
+		registerVars(r, x.Params)
+		var actual RequestModel
+		err := b.BindRequest(r, &actual)
+		if x.ExpErr == "" {
+			assert.Nil(t, err, x.Purpose)
+		} else {
+			assert.EqualValues(t, x.ExpErr, fmt.Sprintf("%s", err), x.Purpose)
+		}
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+	}
+}
@@ -0,0 +1,290 @@
+package srest
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/gorilla/schema"
+)
+
+// DefaultBinder is used by Bind, BindJSON and BindRequest.
+var DefaultBinder = NewBinder(nil)
+
+// BinderOptions type. Zero limits take the Default* constants, a negative
+// limit disables it.
+type BinderOptions struct {
+	// TagName is the struct tag of form values and params, default is
+	// "schema".
+	TagName string
+
+	// IgnoreUnknownKeys makes Bind ignore values without a field.
+	// BindRequest always ignores them, requests usually carry more values
+	// than the model needs.
+	IgnoreUnknownKeys bool
+
+	// ZeroEmpty sets fields to their zero value when the value is empty,
+	// by default empty values are skipped.
+	ZeroEmpty bool
+
+	// DisallowUnknownFields makes JSON bodies fail when they have keys
+	// that don't match a field.
+	DisallowUnknownFields bool
+
+	// JSONBodyLimit is the max size in bytes of JSON bodies and BodyLimit
+	// of form, multipart and XML bodies.
+	JSONBodyLimit int64
+	BodyLimit     int64
+
+	// MultipartMemory is the size in bytes of multipart bodies kept in
+	// memory, see http.Request.ParseMultipartForm.
+	MultipartMemory int64
+}
+
+// Binder type decodes requests into models and validates them. Each Binder
+// has its own options and converters.
+type Binder struct {
+	options BinderOptions
+	form    *schema.Decoder
+	req     *schema.Decoder
+	sources map[string]*schema.Decoder
+	deps    []dependency
+}
+
+// dependency is a context value added by Provide.
+type dependency struct {
+	key, value interface{}
+}
+
+// NewBinder returns a new Binder, options can be nil.
+func NewBinder(options *BinderOptions) *Binder {
+	b := &Binder{sources: make(map[string]*schema.Decoder)}
+	if options != nil {
+		b.options = *options
+	}
+	if b.options.TagName == "" {
+		b.options.TagName = "schema"
+	}
+	b.form = b.newDecoder(b.options.TagName, b.options.IgnoreUnknownKeys)
+	b.req = b.newDecoder(b.options.TagName, true)
+	for _, s := range sources {
+		b.sources[s.tag] = b.newDecoder(s.tag, true)
+	}
+	return b
+}
+
+func (b *Binder) newDecoder(tag string, ignoreUnknown bool) *schema.Decoder {
+	dec := schema.NewDecoder()
+	dec.SetAliasTag(tag)
+	dec.IgnoreUnknownKeys(ignoreUnknown)
+	dec.ZeroEmpty(b.options.ZeroEmpty)
+	return dec
+}
+
+// RegisterConverter sets the converter of values with the type of value,
+// e.g. time.Time{}, for every source of b. The converter returns an invalid
+// reflect.Value when the string can't be converted. Register converters
+// before b is used.
+func (b *Binder) RegisterConverter(value interface{}, f func(string) reflect.Value) {
+	b.form.RegisterConverter(value, f)
+	b.req.RegisterConverter(value, f)
+	for _, dec := range b.sources {
+		dec.RegisterConverter(value, f)
+	}
+}
+
+// Provide adds value with key to the context ValidContext receives, e.g. a
+// DB handle. key must be comparable and should be of an unexported type like
+// context.WithValue keys. Provide dependencies before b is used.
+func (b *Binder) Provide(key, value interface{}) {
+	b.deps = append(b.deps, dependency{key, value})
+}
+
+// context returns ctx with the dependencies.
+func (b *Binder) context(ctx context.Context) context.Context {
+	for _, d := range b.deps {
+		ctx = context.WithValue(ctx, d.key, d.value)
+	}
+	return ctx
+}
+
+// Bind decodes vars into dst, see Bind function.
+func (b *Binder) Bind(vars url.Values, dst interface{}) error {
+	return b.BindContext(context.Background(), vars, dst)
+}
+
+// BindContext is like Bind with ctx for ContextModeler, messages are
+// translated to the locale of WithLocale.
+func (b *Binder) BindContext(ctx context.Context, vars url.Values, dst interface{}) error {
+	var errs ValidationErrors
+	if err := fieldErrors(&errs, b.form.Decode(dst, vars)); err != nil {
+		return err
+	}
+	return localize(contextLocale(ctx), isValid(b.context(ctx), dst, errs, b.options.TagName))
+}
+
+// BindJSON decodes the JSON body of r into dst and runs IsValid method from
+// it like Bind. Content-Type must be application/json or application/*+json,
+// otherwise ErrUnsupportedMediaType is returned. Bodies larger than
+// JSONBodyLimit return ErrBodyTooLarge and malformed ones a *JSONError,
+// values of the wrong type are returned in ValidationErrors with messages
+// translated to Locale(r). ContextModeler receives the request context.
+func (b *Binder) BindJSON(r *http.Request, dst interface{}) error {
+	if !isJSON(r.Header.Get("Content-Type")) {
+		return ErrUnsupportedMediaType
+	}
+	var errs ValidationErrors
+	if err := fieldErrors(&errs, b.decodeJSON(r, dst)); err != nil {
+		return err
+	}
+	return localize(Locale(r), isValid(b.context(r.Context()), dst, errs, b.options.TagName))
+}
+
+// BindRequest decodes query values, body and path params of r into dst, in
+// that order so path params take precedence over the body and the body over
+// the query. The body decoder is chosen by Content-Type:
+//
+//	application/x-www-form-urlencoded	TagName tags
+//	multipart/form-data			TagName tags
+//	application/json, application/*+json	json tags, see BindJSON
+//	application/xml, text/xml, */*+xml	xml tags
+//
+// Requests without Content-Type only bind query and path params, any other
+// type returns ErrUnsupportedMediaType. Keys without a matching field are
+// ignored.
+//
+// Then fields tagged with a source are set from it, e.g.:
+//
+//	ID     int    `path:"id"`
+//	Q      string `query:"q"`
+//	Tenant string `header:"X-Tenant,required"`
+//	Token  string `cookie:"session"`
+//
+// Those fields are not bound from form values and params unless they have a
+// TagName tag too, use `json:"-"` to ignore them in JSON bodies. Values are
+// converted like Bind.
+//
+// Multipart files are bound to *multipart.FileHeader or
+// []*multipart.FileHeader fields with file tag, optionally limiting the size
+// of each file and the types detected from their content:
+//
+//	Avatar *multipart.FileHeader   `file:"avatar,max=2MB,mime=image/png image/jpeg"`
+//	Docs   []*multipart.FileHeader `file:"docs,mime=application/pdf"`
+//
+// Files bigger than MultipartMemory are written to os.TempDir, BodyLimit
+// limits the whole body. At last IsValid method from dst runs like Bind and
+// messages are translated to Locale(r).
+func (b *Binder) BindRequest(r *http.Request, dst interface{}) error {
+	errs, err := b.decodeRequest(r, dst)
+	if err != nil {
+		return err
+	}
+	return localize(Locale(r), isValid(b.context(r.Context()), dst, errs, b.options.TagName))
+}
+
+// decodeRequest decodes every source of r into dst, fields that can't be
+// decoded are returned in ValidationErrors.
+func (b *Binder) decodeRequest(r *http.Request, dst interface{}) (ValidationErrors, error) {
+	query, params := splitQuery(r.URL.Query())
+	var errs ValidationErrors
+	if err := fieldErrors(&errs, b.decodeValues(dst, query)); err != nil {
+		return nil, err
+	}
+	if err := fieldErrors(&errs, b.decodeBody(r, dst)); err != nil {
+		return nil, err
+	}
+	if err := decodeFiles(r, dst, &errs); err != nil {
+		return nil, err
+	}
+	if err := fieldErrors(&errs, b.decodeValues(dst, params)); err != nil {
+		return nil, err
+	}
+	if err := b.decodeSources(r, dst, &errs); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// decodeBody decodes the body of r by its Content-Type.
+func (b *Binder) decodeBody(r *http.Request, dst interface{}) error {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return nil
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ErrUnsupportedMediaType
+	}
+	if r.Body == nil {
+		return ErrEmptyBody
+	}
+	limit := bodyLimit(b.options.BodyLimit, DefaultBodyLimit)
+	switch {
+	case isJSON(ct):
+		return b.decodeJSON(r, dst)
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		err := xml.NewDecoder(limitBody(r.Body, limit)).Decode(dst)
+		if err == io.EOF {
+			return ErrEmptyBody
+		}
+		return parseError(err)
+	case mt == "application/x-www-form-urlencoded":
+		r.Body = limitBody(r.Body, limit)
+		if err := r.ParseForm(); err != nil {
+			return parseError(err)
+		}
+		return b.decodeValues(dst, r.PostForm)
+	case mt == "multipart/form-data":
+		r.Body = limitBody(r.Body, limit)
+		if err := r.ParseMultipartForm(bodyLimit(b.options.MultipartMemory, DefaultMultipartMemory)); err != nil {
+			return parseError(err)
+		}
+		return b.decodeValues(dst, r.MultipartForm.Value)
+	}
+	return ErrUnsupportedMediaType
+}
+
+// decodeJSON decodes the body of r into dst.
+func (b *Binder) decodeJSON(r *http.Request, dst interface{}) error {
+	if r.Body == nil {
+		return ErrEmptyBody
+	}
+	return b.decodeJSONFrom(limitBody(r.Body, bodyLimit(b.options.JSONBodyLimit, DefaultJSONBodyLimit)), dst)
+}
+
+// decodeJSONFrom decodes a JSON value of rd into dst.
+func (b *Binder) decodeJSONFrom(rd io.Reader, dst interface{}) error {
+	cr := &countReader{r: rd}
+	dec := json.NewDecoder(cr)
+	if b.options.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(dst); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return &JSONError{Offset: cr.n, Err: err}
+		}
+		return jsonError(dec, err)
+	}
+	if dec.More() {
+		return &JSONError{Offset: dec.InputOffset(), Err: errors.New("unexpected data after value")}
+	}
+	return nil
+}
+
+// bodyLimit returns def when n is zero and 0 (no limit) when n is negative.
+func bodyLimit(n, def int64) int64 {
+	switch {
+	case n == 0:
+		return def
+	case n < 0:
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,135 @@
+package srest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// FormModel struct uses a custom tag and a converted type.
+type FormModel struct {
+	Name string    `form:"name" validate:"required"`
+	Day  time.Time `form:"day"`
+	Note string    `form:"note"`
+}
+
+func TestBinder(t *testing.T) {
+	b := NewBinder(&BinderOptions{TagName: "form", IgnoreUnknownKeys: true, ZeroEmpty: true})
+	b.RegisterConverter(time.Time{}, func(s string) reflect.Value {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return reflect.Value{}
+		}
+		return reflect.ValueOf(d)
+	})
+
+	table := []struct {
+		Purpose string
+		Values  url.Values
+		Exp     FormModel
+		ExpErr  string
+	}{
+		{
+			"1. OK: converter and unknown keys",
+			url.Values{"name": {"x"}, "day": {"2016-02-01"}, "other": {"x"}},
+			FormModel{Name: "x", Day: time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC), Note: "before"},
+			"",
+		},
+		{
+			"2. OK: zero empty",
+			url.Values{"name": {"x"}, "note": {""}},
+			FormModel{Name: "x"},
+			"",
+		},
+		{
+			"3. Fail: converter and field names by tag",
+			url.Values{"day": {"01/02/2016"}},
+			FormModel{Note: "before"},
+			"srest: validation failed: day has an invalid value; name is required",
+		},
+	}
+	for _, x := range table {
+		actual := FormModel{Note: "before"}
+		err := b.Bind(x.Values, &actual)
+		if x.ExpErr == "" {
+			assert.Nil(t, err, x.Purpose)
+		} else {
+			assert.EqualValues(t, x.ExpErr, fmt.Sprintf("%s", err), x.Purpose)
+		}
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+	}
+
+	// DefaultBinder doesn't have the options nor the converter.
+	var y FormModel
+	err := Bind(url.Values{"name": {"x"}, "day": {"2016-02-01"}}, &y)
+	assert.EqualValues(t, "srest: validation failed: day has an invalid value", fmt.Sprintf("%s", err))
+	err = Bind(url.Values{"Name": {"x"}, "other": {"x"}}, &y)
+	assert.EqualValues(t, "srest: validation failed: other is not allowed", fmt.Sprintf("%s", err))
+}
+
+// usersKey is the context key of the taken usernames.
+type usersKey struct{}
+
+// tenantKey is a request context key.
+type tenantKey struct{}
+
+// UniqueUser struct checks the name against a provided dependency.
+type UniqueUser struct {
+	Name string `schema:"name" validate:"required"`
+}
+
+// IsValid must not be called, ValidContext is preferred.
+func (m *UniqueUser) IsValid() error {
+	return errors.New("IsValid called")
+}
+
+// ValidContext implements ContextModeler interface.
+func (m *UniqueUser) ValidContext(ctx context.Context) error {
+	users, _ := ctx.Value(usersKey{}).(map[string]bool)
+	if users[m.Name] {
+		return fmt.Errorf("%s is taken in %v", m.Name, ctx.Value(tenantKey{}))
+	}
+	return nil
+}
+
+func TestBinderValidContext(t *testing.T) {
+	b := NewBinder(nil)
+	b.Provide(usersKey{}, map[string]bool{"taken": true})
+
+	table := []struct {
+		Purpose string
+		Name    string
+		Exp     string
+	}{
+		{"1. OK", "free", ""},
+		{"2. Fail: dependency and request context", "taken", "taken is taken in acme"},
+		{"3. Fail: tags run first", "", "srest: validation failed: name is required"},
+	}
+	for _, x := range table {
+		r := httptest.NewRequest("GET", "/?name="+x.Name, nil)
+		r = r.WithContext(context.WithValue(r.Context(), tenantKey{}, "acme"))
+		var u UniqueUser
+		err := b.BindRequest(r, &u)
+		if x.Exp == "" {
+			assert.Nil(t, err, x.Purpose)
+			continue
+		}
+		assert.EqualValues(t, x.Exp, fmt.Sprintf("%s", err), x.Purpose)
+	}
+
+	var u UniqueUser
+	ctx := context.WithValue(context.Background(), tenantKey{}, "other")
+	err := b.BindContext(ctx, url.Values{"name": {"taken"}}, &u)
+	assert.EqualValues(t, "taken is taken in other", fmt.Sprintf("%s", err))
+
+	// DefaultBinder doesn't have the dependency.
+	err = Bind(url.Values{"name": {"taken"}}, &u)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,147 @@
+package srest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// DefaultBulkLimit is the max number of items accepted by bulk endpoints
+	// when Options.BulkLimit is zero.
+	DefaultBulkLimit = 100
+)
+
+// Bulker interface can be implemented by RESTfuler types in order to accept
+// batches of items. Use generates endpoints for:
+// BulkCreate : POST	path/_bulk
+// BulkUpdate : PUT	path/_bulk
+// BulkDelete : DELETE	path/_bulk
+//
+// Request body must be a JSON array of at most Options.BulkLimit items and
+// Options.Binder BodyLimit bytes, reading stops once a limit is passed.
+// Every item is decoded into the value returned by BulkModel and validated
+// by Options.Binder like BindJSON before being passed to the Bulker method,
+// items that fail are reported without calling it. Validation errors are
+// reported with 422, other errors of IsValid or ValidContext are logged and
+// reported with 500.
+type Bulker interface {
+	BulkModel() Modeler
+	BulkCreate(r *http.Request, item Modeler) BulkResult
+	BulkUpdate(r *http.Request, item Modeler) BulkResult
+	BulkDelete(r *http.Request, item Modeler) BulkResult
+}
+
+// BulkResult type is the per item result of a bulk request. When Status is
+// zero it defaults to 201 for create, 200 for update and delete or 500 when
+// Error is not empty.
+type BulkResult struct {
+	Index  int         `json:"index"`
+	Status int         `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// bulkHandler decodes the batch and calls fn for every valid item. Response
+// status is 200 when all items succeed and 207 otherwise.
+func bulkHandler(m *SREST, b Bulker, okStatus int, fn func(*http.Request, Modeler) BulkResult) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := m.Options.BulkLimit
+		if limit < 1 {
+			limit = DefaultBulkLimit
+		}
+		binder := m.Options.Binder
+		if binder == nil {
+			binder = DefaultBinder
+		}
+		body := limitBody(r.Body, bodyLimit(binder.options.BodyLimit, DefaultBodyLimit))
+		items, err := decodeBulk(body, limit)
+		switch {
+		case err == errTooManyItems:
+			http.Error(w, fmt.Sprintf("bulk: too many items: more than %d", limit), http.StatusRequestEntityTooLarge)
+			return
+		case bodyError(err) == ErrBodyTooLarge:
+			http.Error(w, "bulk: "+ErrBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			http.Error(w, fmt.Sprintf("bulk: invalid body: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		status := http.StatusOK
+		res := make([]BulkResult, len(items))
+		for i := range items {
+			res[i] = bulkItem(m, binder, r, b, items[i], okStatus, fn)
+			res[i].Index = i
+			if res[i].Status < 200 || res[i].Status > 299 {
+				status = http.StatusMultiStatus
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			m.log().Error("bulk: write response", "route", r.URL.Path, "err", err)
+		}
+	})
+}
+
+// errTooManyItems is returned by decodeBulk when the batch exceeds the limit.
+var errTooManyItems = errors.New("too many items")
+
+// decodeBulk decodes the items of a JSON array one by one, it stops reading
+// once there are more than limit items.
+func decodeBulk(r io.Reader, limit int) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(r)
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if t != json.Delim('[') {
+		return nil, fmt.Errorf("expected array, found %v", t)
+	}
+	var items []json.RawMessage
+	for dec.More() {
+		if len(items) == limit {
+			return nil, errTooManyItems
+		}
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// bulkItem decodes and validates raw with binder and calls fn with it.
+func bulkItem(m *SREST, binder *Binder, r *http.Request, b Bulker, raw json.RawMessage, okStatus int, fn func(*http.Request, Modeler) BulkResult) BulkResult {
+	item := b.BulkModel()
+	var errs ValidationErrors
+	if err := fieldErrors(&errs, binder.decodeJSONFrom(bytes.NewReader(raw), item)); err != nil {
+		return BulkResult{Status: http.StatusBadRequest, Error: err.Error()}
+	}
+	err := localize(Locale(r), isValid(binder.context(r.Context()), item, errs, binder.options.TagName))
+	switch err.(type) {
+	case nil:
+	case ValidationErrors, *FieldError:
+		return BulkResult{Status: http.StatusUnprocessableEntity, Error: err.Error()}
+	default:
+		m.log().Error("bulk", "method", r.Method, "path", r.URL.Path, "err", err)
+		return BulkResult{Status: http.StatusInternalServerError, Error: http.StatusText(http.StatusInternalServerError)}
+	}
+	res := fn(r, item)
+	if res.Status == 0 {
+		res.Status = okStatus
+		if res.Error != "" {
+			res.Status = http.StatusInternalServerError
+		}
+	}
+	return res
+}
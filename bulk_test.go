@@ -0,0 +1,198 @@
+package srest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// BulkAPI satisfies RESTfuler and Bulker interfaces.
+type BulkAPI struct {
+	API
+}
+
+// BulkModel implements Bulker.
+func (a *BulkAPI) BulkModel() Modeler {
+	return &Thing{}
+}
+
+// BulkCreate implements Bulker.
+func (a *BulkAPI) BulkCreate(r *http.Request, item Modeler) BulkResult {
+	t := item.(*Thing)
+	if t.Name == "fail" {
+		return BulkResult{Error: "create failed"}
+	}
+	return BulkResult{Data: t.Name}
+}
+
+// BulkUpdate implements Bulker.
+func (a *BulkAPI) BulkUpdate(r *http.Request, item Modeler) BulkResult {
+	return BulkResult{Status: http.StatusAccepted}
+}
+
+// BulkDelete implements Bulker.
+func (a *BulkAPI) BulkDelete(r *http.Request, item Modeler) BulkResult {
+	return BulkResult{}
+}
+
+// Thing is the bulk item model.
+type Thing struct {
+	Name string `json:"name"`
+}
+
+// IsValid implements Modeler.
+func (t *Thing) IsValid() error {
+	if t.Name == "" {
+		return NewFieldError("name", "required", "")
+	}
+	return nil
+}
+
+func TestBulk(t *testing.T) {
+	m := New(&Options{BulkLimit: 3})
+	m.Use("/things", &BulkAPI{})
+	err := m.registerHandlers()
+	assert.Nil(t, err)
+	ts := httptest.NewServer(m.Mux)
+	defer ts.Close()
+
+	table := []struct {
+		Purpose, Method, Body string
+		Code                  int
+		Exp                   []BulkResult
+	}{
+		{
+			"1. OK: create",
+			"POST", `[{"name":"a"},{"name":"b"}]`,
+			http.StatusOK,
+			[]BulkResult{{0, 201, "a", ""}, {1, 201, "b", ""}},
+		},
+		{
+			"2. OK: create mixed results",
+			"POST", `[{"name":"a"},{"name":""},{"name":"fail"}]`,
+			http.StatusMultiStatus,
+			[]BulkResult{{0, 201, "a", ""}, {1, 422, nil, "name is required"}, {2, 500, nil, "create failed"}},
+		},
+		{
+			"3. OK: update",
+			"PUT", `[{"name":"a"}]`,
+			http.StatusOK,
+			[]BulkResult{{0, 202, nil, ""}},
+		},
+		{
+			"4. OK: delete",
+			"DELETE", `[{"name":"a"},{"name":1}]`,
+			http.StatusMultiStatus,
+			[]BulkResult{{0, 200, nil, ""}, {1, 422, nil, "srest: validation failed: name has an invalid value"}},
+		},
+		{
+			"5. Fail: too many items",
+			"POST", `[{"name":"a"},{"name":"b"},{"name":"c"},{"name":"d"}]`,
+			http.StatusRequestEntityTooLarge,
+			nil,
+		},
+		{
+			"6. Fail: too many items stops reading",
+			"POST", `[{"name":"a"},{"name":"b"},{"name":"c"},{"name":"d"},` + strings.Repeat(`{"name":"x"},`, 1000) + `garbage`,
+			http.StatusRequestEntityTooLarge,
+			nil,
+		},
+		{
+			"7. Fail: invalid body",
+			"POST", `{"name":"a"}`,
+			http.StatusBadRequest,
+			nil,
+		},
+	}
+	for _, x := range table {
+		req, err := http.NewRequest(x.Method, ts.URL+"/things/_bulk", strings.NewReader(x.Body))
+		assert.Nil(t, err, x.Purpose)
+		res, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err, x.Purpose)
+		assert.EqualValues(t, x.Code, res.StatusCode, x.Purpose)
+
+		if x.Exp != nil {
+			var actual []BulkResult
+			err = json.NewDecoder(res.Body).Decode(&actual)
+			assert.Nil(t, err, x.Purpose)
+			assert.EqualValues(t, x.Exp, actual, x.Purpose)
+		}
+		err = res.Body.Close()
+		assert.Nil(t, err, x.Purpose)
+	}
+}
+
+// TaggedAPI satisfies RESTfuler and Bulker interfaces with a tagged model.
+type TaggedAPI struct {
+	BulkAPI
+}
+
+// BulkModel implements Bulker.
+func (a *TaggedAPI) BulkModel() Modeler {
+	return &TaggedThing{}
+}
+
+// BulkCreate implements Bulker.
+func (a *TaggedAPI) BulkCreate(r *http.Request, item Modeler) BulkResult {
+	t := item.(*TaggedThing)
+	return BulkResult{Data: t.Name + ":" + t.Kind}
+}
+
+// TaggedThing is validated by tags.
+type TaggedThing struct {
+	Name string `json:"name" mod:"trim,lower" validate:"required,min=2"`
+	Kind string `json:"kind" default:"thing"`
+}
+
+// IsValid implements Modeler.
+func (t *TaggedThing) IsValid() error {
+	if t.Name == "down" {
+		return errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	}
+	return nil
+}
+
+func TestBulkBinder(t *testing.T) {
+	m := New(&Options{Binder: NewBinder(&BinderOptions{DisallowUnknownFields: true})})
+	m.Use("/things", &TaggedAPI{})
+	err := m.registerHandlers()
+	assert.Nil(t, err)
+	ts := httptest.NewServer(m.Mux)
+	defer ts.Close()
+
+	res, err := http.Post(ts.URL+"/things/_bulk", "application/json", strings.NewReader(
+		`[{"name":" AB "},{"name":"a"},{"name":"ab","other":1},{"name":"down"}]`))
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusMultiStatus, res.StatusCode)
+	var actual []BulkResult
+	err = json.NewDecoder(res.Body).Decode(&actual)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []BulkResult{
+		{0, 201, "ab:thing", ""},
+		{1, 422, nil, "srest: validation failed: name must be at least 2"},
+		{2, 400, nil, `srest: invalid JSON at offset 23: unknown field "other"`},
+		{3, 500, nil, "Internal Server Error"},
+	}, actual)
+	err = res.Body.Close()
+	assert.Nil(t, err)
+}
+
+func TestBulkBodyLimit(t *testing.T) {
+	m := New(&Options{Binder: NewBinder(&BinderOptions{BodyLimit: 20})})
+	m.Use("/things", &BulkAPI{})
+	err := m.registerHandlers()
+	assert.Nil(t, err)
+	ts := httptest.NewServer(m.Mux)
+	defer ts.Close()
+
+	res, err := http.Post(ts.URL+"/things/_bulk", "application/json", strings.NewReader(`[{"name":"aaaaaaaaaaaaaaaaaaaa"}]`))
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	err = res.Body.Close()
+	assert.Nil(t, err)
+}
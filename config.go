@@ -0,0 +1,308 @@
+package srest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of environment variables read by LoadOptions,
+// e.g. SREST_ADDR overrides the addr key.
+const EnvPrefix = "SREST_"
+
+// OptionsError type is returned by LoadOptions with every invalid key.
+type OptionsError struct {
+	Keys []KeyError
+}
+
+// KeyError type is an invalid configuration key. Source is the file name or
+// the environment variable.
+type KeyError struct {
+	Source string
+	Key    string
+	Err    error
+}
+
+// Error implements error interface.
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Source, e.Key, e.Err)
+}
+
+// Error implements error interface.
+func (e *OptionsError) Error() string {
+	s := make([]string, len(e.Keys))
+	for i := range e.Keys {
+		s[i] = e.Keys[i].Error()
+	}
+	return "srest: invalid options: " + strings.Join(s, "; ")
+}
+
+// LoadOptions reads Options from the file name and SREST_* environment
+// variables, the latter take precedence. Empty name reads the environment
+// only. Files ending with .json are a JSON object, any other file has a
+// "key: value" or "key = value" pair per line, lines starting with # and
+// text after " #" outside quotes are comments. Keys are:
+//
+//	addr, use_tls, tls_cert, tls_key, tls_reload, tls_reload_interval,
+//	client_cas, client_auth, tls_min_version, dev_cert, dev_hosts,
+//	dev_cert_dir, bulk_limit, shutdown_timeout, shutdown_delay,
+//	read_timeout, read_header_timeout, write_timeout, idle_timeout,
+//	max_header_bytes, redirect_http, hsts_max_age, hsts_include_subdomains,
+//	h2c, access_log, upgrade, upgrade_timeout, views, debug
+//
+// Durations use time.ParseDuration format and lists are comma separated.
+// Unknown or invalid keys are returned together in an *OptionsError.
+func LoadOptions(name string) (*Options, error) {
+	o := &Options{}
+	var errs []KeyError
+	if name != "" {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var kv map[string]string
+		if strings.EqualFold(filepath.Ext(name), ".json") {
+			kv, err = parseJSONOptions(b)
+		} else {
+			kv, err = parseTextOptions(b)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("srest: %s: %s", name, err)
+		}
+		keys := make([]string, 0, len(kv))
+		for k := range kv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := setOption(o, k, kv[k]); err != nil {
+				errs = append(errs, KeyError{Source: name, Key: k, Err: err})
+			}
+		}
+	}
+
+	// Only known keys are read from the environment, other SREST_*
+	// variables are used internally, see Options.Upgrade.
+	for _, k := range optionKeys() {
+		env := EnvPrefix + strings.ToUpper(k)
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		if err := setOption(o, k, v); err != nil {
+			errs = append(errs, KeyError{Source: "env", Key: env, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, &OptionsError{Keys: errs}
+	}
+	return o, nil
+}
+
+// parseTextOptions parses "key: value" and "key = value" lines.
+func parseTextOptions(b []byte) (map[string]string, error) {
+	kv := make(map[string]string)
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		j := strings.IndexAny(line, ":=")
+		if j < 1 {
+			return nil, fmt.Errorf("line %d: expected key: value", i+1)
+		}
+		k := strings.TrimSpace(line[:j])
+		v := stripComment(strings.TrimSpace(line[j+1:]))
+		v = strings.TrimSuffix(strings.TrimPrefix(v, "["), "]")
+		if uv, err := strconv.Unquote(v); err == nil {
+			v = uv
+		}
+		kv[k] = v
+	}
+	return kv, nil
+}
+
+// stripComment removes a trailing "# comment" outside quotes, # must follow
+// a space so values like "a#b" are kept.
+func stripComment(v string) string {
+	quoted := false
+	for i := 0; i < len(v); i++ {
+		switch {
+		case v[i] == '\\' && quoted:
+			i++
+		case v[i] == '"':
+			quoted = !quoted
+		case v[i] == '#' && !quoted && i > 0 && (v[i-1] == ' ' || v[i-1] == '\t'):
+			return strings.TrimSpace(v[:i])
+		}
+	}
+	return v
+}
+
+// parseJSONOptions parses a JSON object, arrays are joined by commas.
+// Numbers are kept as written, e.g. 1048576 instead of 1.048576e+06.
+func parseJSONOptions(b []byte) (map[string]string, error) {
+	var raw map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after object")
+	}
+	kv := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch x := v.(type) {
+		case []interface{}:
+			s := make([]string, len(x))
+			for i := range x {
+				s[i] = fmt.Sprint(x[i])
+			}
+			kv[k] = strings.Join(s, ",")
+		case nil:
+			kv[k] = ""
+		default:
+			kv[k] = fmt.Sprint(x)
+		}
+	}
+	return kv, nil
+}
+
+// optionSetters maps configuration keys to Options fields.
+var optionSetters = map[string]func(o *Options, v string) error{
+	"addr":                    func(o *Options, v string) error { o.Addr = v; return nil },
+	"use_tls":                 boolOption(func(o *Options) *bool { return &o.UseTLS }),
+	"tls_cert":                func(o *Options, v string) error { o.TLSCert = v; return nil },
+	"tls_key":                 func(o *Options, v string) error { o.TLSKey = v; return nil },
+	"tls_reload":              boolOption(func(o *Options) *bool { return &o.TLSReload }),
+	"tls_reload_interval":     durationOption(func(o *Options) *time.Duration { return &o.TLSReloadInterval }),
+	"client_cas":              func(o *Options, v string) error { o.ClientCAs = splitList(v); return nil },
+	"client_auth":             setClientAuth,
+	"tls_min_version":         setTLSMinVersion,
+	"dev_cert":                boolOption(func(o *Options) *bool { return &o.DevCert }),
+	"dev_hosts":               func(o *Options, v string) error { o.DevHosts = splitList(v); return nil },
+	"dev_cert_dir":            func(o *Options, v string) error { o.DevCertDir = v; return nil },
+	"bulk_limit":              intOption(func(o *Options) *int { return &o.BulkLimit }),
+	"shutdown_timeout":        durationOption(func(o *Options) *time.Duration { return &o.ShutdownTimeout }),
+	"shutdown_delay":          durationOption(func(o *Options) *time.Duration { return &o.ShutdownDelay }),
+	"read_timeout":            durationOption(func(o *Options) *time.Duration { return &o.ReadTimeout }),
+	"read_header_timeout":     durationOption(func(o *Options) *time.Duration { return &o.ReadHeaderTimeout }),
+	"write_timeout":           durationOption(func(o *Options) *time.Duration { return &o.WriteTimeout }),
+	"idle_timeout":            durationOption(func(o *Options) *time.Duration { return &o.IdleTimeout }),
+	"max_header_bytes":        intOption(func(o *Options) *int { return &o.MaxHeaderBytes }),
+	"redirect_http":           func(o *Options, v string) error { o.RedirectHTTP = v; return nil },
+	"hsts_max_age":            durationOption(func(o *Options) *time.Duration { return &o.HSTSMaxAge }),
+	"hsts_include_subdomains": boolOption(func(o *Options) *bool { return &o.HSTSIncludeSubdomains }),
+	"h2c":                     boolOption(func(o *Options) *bool { return &o.H2C }),
+	"access_log":              boolOption(func(o *Options) *bool { return &o.AccessLog }),
+	"upgrade":                 boolOption(func(o *Options) *bool { return &o.Upgrade }),
+	"upgrade_timeout":         durationOption(func(o *Options) *time.Duration { return &o.UpgradeTimeout }),
+	"views":                   func(o *Options, v string) error { o.Views = v; return nil },
+	"debug":                   boolOption(func(o *Options) *bool { return &o.Debug }),
+}
+
+// optionKeys returns the known keys sorted.
+func optionKeys() []string {
+	keys := make([]string, 0, len(optionSetters))
+	for k := range optionSetters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func setOption(o *Options, k, v string) error {
+	f, ok := optionSetters[strings.ToLower(k)]
+	if !ok {
+		return fmt.Errorf("unknown key")
+	}
+	return f(o, v)
+}
+
+func boolOption(field func(*Options) *bool) func(*Options, string) error {
+	return func(o *Options, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", v)
+		}
+		*field(o) = b
+		return nil
+	}
+}
+
+func intOption(field func(*Options) *int) func(*Options, string) error {
+	return func(o *Options, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*field(o) = n
+		return nil
+	}
+}
+
+func durationOption(field func(*Options) *time.Duration) func(*Options, string) error {
+	return func(o *Options, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*field(o) = d
+		return nil
+	}
+}
+
+func setClientAuth(o *Options, v string) error {
+	auth := map[string]tls.ClientAuthType{
+		"none":               tls.NoClientCert,
+		"request":            tls.RequestClientCert,
+		"require":            tls.RequireAnyClientCert,
+		"verify_if_given":    tls.VerifyClientCertIfGiven,
+		"require_and_verify": tls.RequireAndVerifyClientCert,
+	}
+	a, ok := auth[strings.ToLower(v)]
+	if !ok {
+		return fmt.Errorf("invalid client auth %q", v)
+	}
+	o.ClientAuth = a
+	return nil
+}
+
+func setTLSMinVersion(o *Options, v string) error {
+	versions := map[string]uint16{
+		"1.0": tls.VersionTLS10,
+		"1.1": tls.VersionTLS11,
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+	ver, ok := versions[v]
+	if !ok {
+		return fmt.Errorf("invalid TLS version %q", v)
+	}
+	o.TLSMinVersion = ver
+	return nil
+}
+
+// splitList splits a comma separated list dropping empty items.
+func splitList(v string) []string {
+	var res []string
+	for _, s := range strings.Split(v, ",") {
+		s = strings.TrimSpace(s)
+		if uv, err := strconv.Unquote(s); err == nil {
+			s = uv
+		}
+		if s != "" {
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,184 @@
+package srest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "srest")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+
+	table := []struct {
+		Purpose string
+		Name    string
+		Content string
+		Env     map[string]string
+		Exp     *Options
+		ExpErr  string
+	}{
+		{
+			"1. OK: text file",
+			"a.conf",
+			"# server\naddr: 127.0.0.1:8080\nuse_tls = true\ntls_cert: \"a.pem\"\n" +
+				"client_cas: [ca.pem, other.pem]\nclient_auth: verify_if_given\n" +
+				"tls_min_version: 1.3\nread_timeout: 5s\nmax_header_bytes: 4096\n" +
+				"views: views\ndebug: true\n",
+			nil,
+			&Options{
+				Addr:           "127.0.0.1:8080",
+				UseTLS:         true,
+				TLSCert:        "a.pem",
+				ClientCAs:      []string{"ca.pem", "other.pem"},
+				ClientAuth:     tls.VerifyClientCertIfGiven,
+				TLSMinVersion:  tls.VersionTLS13,
+				ReadTimeout:    5 * time.Second,
+				MaxHeaderBytes: 4096,
+				Views:          "views",
+				Debug:          true,
+			},
+			"",
+		},
+		{
+			"2. OK: JSON file with environment overrides",
+			"b.json",
+			`{"addr": ":8080", "h2c": true, "dev_hosts": ["a.test", "b.test"], "bulk_limit": 10}`,
+			map[string]string{"SREST_ADDR": ":9090", "SREST_SHUTDOWN_TIMEOUT": "1m"},
+			&Options{
+				Addr:            ":9090",
+				H2C:             true,
+				DevHosts:        []string{"a.test", "b.test"},
+				BulkLimit:       10,
+				ShutdownTimeout: time.Minute,
+			},
+			"",
+		},
+		{
+			"3. OK: JSON large integer",
+			"e.json",
+			`{"max_header_bytes": 1048576, "shutdown_delay": "1.5s"}`,
+			nil,
+			&Options{MaxHeaderBytes: 1 << 20, ShutdownDelay: 1500 * time.Millisecond},
+			"",
+		},
+		{
+			"4. OK: trailing comments",
+			"f.conf",
+			"views: \"x # y\" # templates\naddr: :8080 # port\nredirect_http: a#b\ndev_hosts: [a.test, b.test] # hosts\n",
+			nil,
+			&Options{Views: "x # y", Addr: ":8080", RedirectHTTP: "a#b", DevHosts: []string{"a.test", "b.test"}},
+			"",
+		},
+		{
+			"5. OK: environment only",
+			"",
+			"",
+			map[string]string{"SREST_ACCESS_LOG": "1", "SREST_UNKNOWN": "x"},
+			&Options{AccessLog: true},
+			"",
+		},
+		{
+			"6. Fail: every invalid key",
+			"c.conf",
+			"port: 80\nuse_tls: yes\nread_timeout: 5\ntls_min_version: 1.4\n",
+			map[string]string{"SREST_BULK_LIMIT": "many"},
+			nil,
+			"srest: invalid options: " +
+				dir + "/c.conf: port: unknown key; " +
+				dir + "/c.conf: read_timeout: invalid duration \"5\"; " +
+				dir + "/c.conf: tls_min_version: invalid TLS version \"1.4\"; " +
+				dir + "/c.conf: use_tls: invalid bool \"yes\"; " +
+				"env: SREST_BULK_LIMIT: invalid integer \"many\"",
+		},
+		{
+			"7. Fail: malformed line",
+			"d.conf",
+			"addr\n",
+			nil,
+			nil,
+			"srest: " + dir + "/d.conf: line 1: expected key: value",
+		},
+		{
+			"8. Fail: JSON trailing data",
+			"g.json",
+			`{"addr": ":8080"} {}`,
+			nil,
+			nil,
+			"srest: " + dir + "/g.json: unexpected data after object",
+		},
+		{
+			"9. Fail: file not found",
+			"none.conf",
+			"",
+			nil,
+			nil,
+			"open " + dir + "/none.conf: no such file or directory",
+		},
+	}
+	for _, x := range table {
+		for k, v := range x.Env {
+			err := os.Setenv(k, v)
+			assert.Nil(t, err)
+		}
+		name := x.Name
+		if name != "" {
+			name = dir + "/" + name
+		}
+		if x.Content != "" {
+			err := mkFile(name, x.Content)
+			assert.Nil(t, err)
+		}
+
+		actual, err := LoadOptions(name)
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+		if x.ExpErr == "" {
+			assert.Nil(t, err, x.Purpose)
+		} else {
+			assert.EqualValues(t, x.ExpErr, fmt.Sprintf("%s", err), x.Purpose)
+		}
+
+		for k := range x.Env {
+			err := os.Unsetenv(k)
+			assert.Nil(t, err)
+		}
+	}
+}
+
+func TestRunContextAddr(t *testing.T) {
+	dir, err := getTempDir()
+	assert.Nil(t, err)
+
+	m := New(&Options{Addr: "127.0.0.1:9015", Views: dir + "/a"})
+	m.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = Render(w, "index.html", nil)
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.RunContext(ctx, 0)
+	}()
+	<-time.After(100 * time.Millisecond)
+
+	client := &http.Client{Timeout: time.Second}
+	_, err = getBody(client, "GET", "http://127.0.0.1:9015/")
+	assert.Nil(t, err)
+	cancel()
+	assert.Nil(t, <-done)
+
+	// Views must fail on start.
+	m = New(&Options{Addr: "127.0.0.1:9015", Views: dir + "/none"})
+	err = m.RunContext(context.Background(), 0)
+	assert.NotNil(t, err)
+}
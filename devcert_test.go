@@ -0,0 +1,89 @@
+package srest
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "srest")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+
+	certFile, keyFile, err := DevCert(dir, "app.local")
+	assert.Nil(t, err)
+	assert.EqualValues(t, dir+"/dev-cert.pem", certFile)
+	assert.EqualValues(t, dir+"/dev-key.pem", keyFile)
+	first, err := ioutil.ReadFile(certFile)
+	assert.Nil(t, err)
+
+	table := []struct {
+		Purpose string
+		Hosts   []string
+		Reuse   bool
+	}{
+		{"1. OK: cached", []string{"app.local"}, true},
+		{"2. OK: cached subset", nil, true},
+		{"3. OK: new host", []string{"api.local"}, false},
+	}
+	for _, x := range table {
+		_, _, err := DevCert(dir, x.Hosts...)
+		assert.Nil(t, err, x.Purpose)
+		actual, err := ioutil.ReadFile(certFile)
+		assert.Nil(t, err, x.Purpose)
+		assert.EqualValues(t, x.Reuse, string(first) == string(actual), x.Purpose)
+	}
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	assert.Nil(t, err)
+	for _, h := range []string{"localhost", "127.0.0.1", "::1", "api.local"} {
+		assert.Nil(t, cert.VerifyHostname(h), h)
+	}
+}
+
+func TestDevCertServe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "srest")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+
+	m := New(&Options{UseTLS: true, DevCert: true, DevCertDir: dir})
+	m.Get("/", say("dev"))
+	done := make(chan error, 1)
+	go func() {
+		done <- m.ListenAndServe("127.0.0.1:9006")
+	}()
+	<-time.After(100 * time.Millisecond)
+
+	b, err := ioutil.ReadFile(dir + "/dev-cert.pem")
+	assert.Nil(t, err)
+	pool := x509.NewCertPool()
+	assert.True(t, pool.AppendCertsFromPEM(b))
+	client := &http.Client{
+		Timeout:   time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+	}
+	actual, err := getBody(client, "GET", "https://localhost:9006/")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "dev", actual)
+	client.CloseIdleConnections()
+
+	err = m.Shutdown(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, <-done)
+}
@@ -0,0 +1,161 @@
+package srest
+
+import (
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// decodeFiles sets the fields tagged with `file` from the multipart form of
+// r. The tag has the form name and optional max size and MIME types, e.g.
+// `file:"avatar,max=2MB,mime=image/png image/jpeg"`. Types are detected
+// from the content, see http.DetectContentType, and "image/*" matches any
+// image.
+func decodeFiles(r *http.Request, dst interface{}, errs *ValidationErrors) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	rv = rv.Elem()
+	for _, sf := range sourceFields(rv.Type()) {
+		tag, ok := sf.Tag.Lookup("file")
+		if !ok || tag == "-" {
+			continue
+		}
+		if sf.Type != fileHeaderType && sf.Type != fileHeadersType {
+			return fmt.Errorf("srest: file field %s must be *multipart.FileHeader or []*multipart.FileHeader", sf.Name)
+		}
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+		if name == "" {
+			name = sf.Name
+		}
+		if r.MultipartForm == nil || len(r.MultipartForm.File[name]) < 1 {
+			continue
+		}
+		fhs := r.MultipartForm.File[name]
+		ferr, err := checkFiles(name, fhs, opts[1:])
+		if err != nil {
+			return err
+		}
+		if ferr != nil {
+			*errs = append(*errs, ferr)
+			continue
+		}
+		f := fieldByIndex(rv, sf.Index)
+		if sf.Type == fileHeaderType {
+			f.Set(reflect.ValueOf(fhs[0]))
+		} else {
+			f.Set(reflect.ValueOf(fhs))
+		}
+	}
+	return nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, nil embedded pointers
+// are allocated.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// checkFiles checks the max size and MIME types in opts.
+func checkFiles(name string, fhs []*multipart.FileHeader, opts []string) (*FieldError, error) {
+	for _, opt := range opts {
+		k, v, _ := strings.Cut(opt, "=")
+		switch k {
+		case "max":
+			max, err := parseSize(v)
+			if err != nil {
+				return nil, fmt.Errorf("srest: file field %s: %s", name, err)
+			}
+			for _, fh := range fhs {
+				if fh.Size > max {
+					return NewFieldError(name, "maxsize", v), nil
+				}
+			}
+		case "mime":
+			for _, fh := range fhs {
+				mt, err := sniff(fh)
+				if err != nil {
+					return nil, err
+				}
+				if !matchMIME(mt, strings.Fields(v)) {
+					return NewFieldError(name, "mime", v), nil
+				}
+			}
+		default:
+			return nil, fmt.Errorf("srest: file field %s: unknown option %q", name, opt)
+		}
+	}
+	return nil, nil
+}
+
+// sniff returns the media type detected from the first 512 bytes of fh.
+func sniff(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	b := make([]byte, 512)
+	n, err := f.Read(b)
+	if err != nil && n == 0 && fh.Size > 0 {
+		return "", err
+	}
+	mt, _, err := mime.ParseMediaType(http.DetectContentType(b[:n]))
+	return mt, err
+}
+
+// matchMIME reports if mt is one of types, "type/*" matches any subtype.
+func matchMIME(mt string, types []string) bool {
+	for _, x := range types {
+		if x == mt || strings.HasSuffix(x, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(x, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSize parses sizes like "512", "64KB", "2MB" or "1GB".
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		n      int64
+	}{
+		{"KB", 1 << 10},
+		{"MB", 1 << 20},
+		{"GB", 1 << 30},
+		{"B", 1},
+	}
+	mult := int64(1)
+	v := strings.ToUpper(strings.TrimSpace(s))
+	for _, u := range units {
+		if strings.HasSuffix(v, u.suffix) {
+			v, mult = strings.TrimSuffix(v, u.suffix), u.n
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
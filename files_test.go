@@ -0,0 +1,233 @@
+package srest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var pngData = "\x89PNG\r\n\x1a\n" + "0000000000000000"
+
+// Upload struct binds multipart files.
+type Upload struct {
+	Name   string                  `schema:"name"`
+	Avatar *multipart.FileHeader   `file:"avatar,max=32B,mime=image/*" validate:"required"`
+	Docs   []*multipart.FileHeader `file:"docs,mime=text/plain application/pdf"`
+}
+
+// IsValid modeler interface
+func (m *Upload) IsValid() error {
+	return nil
+}
+
+// upload is a multipart part, File is empty for form values.
+type upload struct {
+	Field, File, Content string
+}
+
+// uploadRequest returns a multipart request with parts.
+func uploadRequest(parts []upload) (*http.Request, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		if p.File == "" {
+			if err := w.WriteField(p.Field, p.Content); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		fw, err := w.CreateFormFile(p.Field, p.File)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write([]byte(p.Content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	r := httptest.NewRequest("POST", "/", &buf)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r, nil
+}
+
+func TestBindRequestFiles(t *testing.T) {
+	table := []struct {
+		Purpose string
+		Parts   []upload
+		Memory  int64
+		Exp     []string
+		ExpErr  string
+	}{
+		{
+			"1. OK: single and multiple files",
+			[]upload{
+				{"name", "", "x"},
+				{"avatar", "a.png", pngData},
+				{"docs", "a.txt", "text a"},
+				{"docs", "b.txt", "text b"},
+			},
+			DefaultMultipartMemory,
+			[]string{"x", "a.png:" + pngData, "a.txt:text a", "b.txt:text b"}, "",
+		},
+		{
+			"2. OK: files on disk and form values can't set file fields",
+			[]upload{
+				{"avatar", "a.png", pngData},
+				{"Docs.Filename", "", "x"},
+			},
+			1,
+			[]string{"", "a.png:" + pngData}, "",
+		},
+		{
+			"3. Fail: required file",
+			[]upload{{"name", "", "x"}},
+			DefaultMultipartMemory,
+			[]string{"x"}, "srest: validation failed: avatar is required",
+		},
+		{
+			"4. Fail: too large",
+			[]upload{{"avatar", "a.png", pngData + "00000000000000000"}},
+			DefaultMultipartMemory,
+			[]string{""}, "srest: validation failed: avatar must not be larger than 32B",
+		},
+		{
+			"5. Fail: sniffed type",
+			[]upload{
+				{"avatar", "a.png", pngData},
+				{"docs", "a.txt", "text a"},
+				{"docs", "b.txt", pngData},
+			},
+			DefaultMultipartMemory,
+			[]string{"", "a.png:" + pngData}, "srest: validation failed: docs must be of type text/plain application/pdf",
+		},
+	}
+	for _, x := range table {
+		b := NewBinder(&BinderOptions{MultipartMemory: x.Memory})
+		r, err := uploadRequest(x.Parts)
+		assert.Nil(t, err, x.Purpose)
+		var u Upload
+		err = b.BindRequest(r, &u)
+		if x.ExpErr == "" {
+			assert.Nil(t, err, x.Purpose)
+		} else {
+			assert.EqualValues(t, x.ExpErr, fmt.Sprintf("%s", err), x.Purpose)
+		}
+
+		actual := []string{u.Name}
+		fhs := u.Docs
+		if u.Avatar != nil {
+			fhs = append([]*multipart.FileHeader{u.Avatar}, fhs...)
+		}
+		for _, fh := range fhs {
+			f, err := fh.Open()
+			assert.Nil(t, err, x.Purpose)
+			b, err := ioutil.ReadAll(f)
+			assert.Nil(t, err, x.Purpose)
+			err = f.Close()
+			assert.Nil(t, err, x.Purpose)
+			actual = append(actual, fh.Filename+":"+string(b))
+		}
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+		if r.MultipartForm != nil {
+			err = r.MultipartForm.RemoveAll()
+			assert.Nil(t, err, x.Purpose)
+		}
+	}
+}
+
+func TestBindRequestFilesFail(t *testing.T) {
+	r, err := uploadRequest([]upload{{"file", "a.txt", "text"}})
+	assert.Nil(t, err)
+
+	var x struct {
+		File string `file:"file"`
+	}
+	err = BindRequest(r, &x)
+	assert.EqualValues(t, "srest: file field File must be *multipart.FileHeader or []*multipart.FileHeader", fmt.Sprintf("%s", err))
+
+	var y struct {
+		File *multipart.FileHeader `file:"file,max=big"`
+	}
+	err = BindRequest(r, &y)
+	assert.EqualValues(t, `srest: file field file: invalid size "big"`, fmt.Sprintf("%s", err))
+
+	var z struct {
+		File *multipart.FileHeader `file:"file,other"`
+	}
+	err = BindRequest(r, &z)
+	assert.EqualValues(t, `srest: file field file: unknown option "other"`, fmt.Sprintf("%s", err))
+}
+
+// Attachment struct is embedded with a file field.
+type Attachment struct {
+	File *multipart.FileHeader `file:"file"`
+}
+
+// attachment struct is an unexported embed, its fields are not bound.
+type attachment struct {
+	Other *multipart.FileHeader `file:"other"`
+}
+
+func TestBindRequestFilesEmbedded(t *testing.T) {
+	var x struct {
+		Attachment
+	}
+	var y struct {
+		*Attachment
+	}
+	var z struct {
+		attachment
+	}
+	table := []struct {
+		Purpose string
+		Dst     interface{}
+		File    func() *multipart.FileHeader
+		Exp     string
+	}{
+		{"1. OK: embedded struct", &x, func() *multipart.FileHeader { return x.File }, "a.txt"},
+		{"2. OK: nil embedded pointer is allocated", &y, func() *multipart.FileHeader { return y.File }, "a.txt"},
+		{"3. OK: unexported embedded struct is skipped", &z, func() *multipart.FileHeader { return z.Other }, ""},
+	}
+	for _, x := range table {
+		r, err := uploadRequest([]upload{{"file", "a.txt", "text"}, {"other", "b.txt", "text"}})
+		assert.Nil(t, err, x.Purpose)
+		_, err = DefaultBinder.decodeRequest(r, x.Dst)
+		assert.Nil(t, err, x.Purpose)
+		actual := ""
+		if fh := x.File(); fh != nil {
+			actual = fh.Filename
+		}
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+		err = r.MultipartForm.RemoveAll()
+		assert.Nil(t, err, x.Purpose)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	table := []struct {
+		Purpose string
+		Input   string
+		Exp     int64
+		ExpErr  bool
+	}{
+		{"1. OK: bytes", "512", 512, false},
+		{"2. OK: KB", "64KB", 64 << 10, false},
+		{"3. OK: MB lowercase", "2mb", 2 << 20, false},
+		{"4. OK: GB", "1GB", 1 << 30, false},
+		{"5. Fail: negative", "-1B", 0, true},
+		{"6. Fail: unit", "1TB", 0, true},
+	}
+	for _, x := range table {
+		actual, err := parseSize(x.Input)
+		assert.EqualValues(t, x.ExpErr, err != nil, x.Purpose)
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+	}
+}
@@ -0,0 +1,128 @@
+package srest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHealthTimeout is used when HealthCheck.Timeout is zero.
+	DefaultHealthTimeout = 5 * time.Second
+
+	// StatusOK and StatusFail are the health status values.
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// HealthCheck type is a named check run by health endpoints.
+type HealthCheck struct {
+	Name    string
+	Timeout time.Duration
+	Check   func(ctx context.Context) error
+}
+
+// HealthStatus type is the response body of health endpoints.
+type HealthStatus struct {
+	Status string        `json:"status"`
+	Checks []CheckStatus `json:"checks,omitempty"`
+}
+
+// CheckStatus type is the result of a HealthCheck.
+type CheckStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Health registers endpoints for:
+// Health : GET		path
+// Liveness : GET	path/live
+// Readiness : GET	path/ready
+//
+// Health and readiness run all checks concurrently and respond 200 when all
+// of them pass or 503 otherwise. Readiness fails as soon as the server
+// starts the graceful shutdown so load balancers stop routing to it, the
+// listeners close after Options.ShutdownDelay, DefaultShutdownDelay by
+// default. Liveness always responds 200. With "/" as path the endpoints are
+// /, /live and /ready.
+func (m *SREST) Health(uri string, checks ...HealthCheck) {
+	m.health = true
+	uri = path.Clean(uri)
+	base := strings.TrimSuffix(uri, "/")
+	m.Get(uri, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.writeHealth(w, runChecks(r.Context(), checks))
+	}))
+	m.Get(base+"/live", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.writeHealth(w, HealthStatus{Status: StatusOK})
+	}))
+	m.Get(base+"/ready", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.draining.Load() {
+			m.writeHealth(w, HealthStatus{Status: StatusFail, Checks: []CheckStatus{
+				{Name: "shutdown", Status: StatusFail, Error: "server is shutting down"},
+			}})
+			return
+		}
+		m.writeHealth(w, runChecks(r.Context(), checks))
+	}))
+}
+
+// runChecks runs every check with its timeout.
+func runChecks(ctx context.Context, checks []HealthCheck) HealthStatus {
+	res := HealthStatus{Status: StatusOK, Checks: make([]CheckStatus, len(checks))}
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i := range checks {
+		go func(i int) {
+			defer wg.Done()
+			res.Checks[i] = runCheck(ctx, checks[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, x := range res.Checks {
+		if x.Status != StatusOK {
+			res.Status = StatusFail
+		}
+	}
+	return res
+}
+
+func runCheck(ctx context.Context, c HealthCheck) CheckStatus {
+	d := c.Timeout
+	if d <= 0 {
+		d = DefaultHealthTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	// Checks that ignore ctx can't block the response.
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return CheckStatus{Name: c.Name, Status: StatusFail, Error: err.Error()}
+	}
+	return CheckStatus{Name: c.Name, Status: StatusOK}
+}
+
+func (m *SREST) writeHealth(w http.ResponseWriter, s HealthStatus) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if s.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		m.log().Error("health: write response", "err", err)
+	}
+}
@@ -0,0 +1,172 @@
+package srest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	var dbErr error
+	var slow atomic.Bool
+	m := New(&Options{ShutdownDelay: 200 * time.Millisecond})
+	m.Health("/health", HealthCheck{
+		Name: "db",
+		Check: func(ctx context.Context) error {
+			return dbErr
+		},
+	}, HealthCheck{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Check: func(ctx context.Context) error {
+			if slow.Load() {
+				<-time.After(time.Second)
+			}
+			return nil
+		},
+	})
+	ts := httptest.NewServer(m)
+	defer ts.Close()
+
+	table := []struct {
+		Purpose, URL string
+		DBErr        error
+		Slow         bool
+		Code         int
+		Exp          HealthStatus
+	}{
+		{
+			"1. OK: live",
+			"/health/live", errors.New("db is down"), true,
+			http.StatusOK,
+			HealthStatus{Status: StatusOK},
+		},
+		{
+			"2. OK: health",
+			"/health", nil, false,
+			http.StatusOK,
+			HealthStatus{StatusOK, []CheckStatus{
+				{"db", StatusOK, ""},
+				{"slow", StatusOK, ""},
+			}},
+		},
+		{
+			"3. Fail: db",
+			"/health/ready", errors.New("db is down"), false,
+			http.StatusServiceUnavailable,
+			HealthStatus{StatusFail, []CheckStatus{
+				{"db", StatusFail, "db is down"},
+				{"slow", StatusOK, ""},
+			}},
+		},
+		{
+			"4. Fail: slow timeout",
+			"/health", nil, true,
+			http.StatusServiceUnavailable,
+			HealthStatus{StatusFail, []CheckStatus{
+				{"db", StatusOK, ""},
+				{"slow", StatusFail, "context deadline exceeded"},
+			}},
+		},
+	}
+	for _, x := range table {
+		dbErr = x.DBErr
+		slow.Store(x.Slow)
+		code, actual, err := getHealth(ts.URL + x.URL)
+		assert.Nil(t, err, x.Purpose)
+		assert.EqualValues(t, x.Code, code, x.Purpose)
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+	}
+
+	// Readiness fails during shutdown delay.
+	dbErr = nil
+	slow.Store(false)
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Shutdown(context.Background())
+	}()
+	<-time.After(50 * time.Millisecond)
+	code, actual, err := getHealth(ts.URL + "/health/ready")
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusServiceUnavailable, code)
+	assert.EqualValues(t, HealthStatus{StatusFail, []CheckStatus{
+		{"shutdown", StatusFail, "server is shutting down"},
+	}}, actual)
+	code, _, err = getHealth(ts.URL + "/health/live")
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusOK, code)
+	assert.Nil(t, <-done)
+}
+
+func TestHealthShutdownDelay(t *testing.T) {
+	m := New(nil)
+	m.Health("/health")
+	ts := httptest.NewServer(m)
+	defer ts.Close()
+
+	// Readiness fails during the default delay, ctx ends it.
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Shutdown(ctx)
+	}()
+	<-time.After(100 * time.Millisecond)
+	code, _, err := getHealth(ts.URL + "/health/ready")
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusServiceUnavailable, code)
+	assert.Nil(t, <-done)
+	assert.True(t, time.Since(start) >= 300*time.Millisecond)
+
+	// Negative delay disables it.
+	m = New(&Options{ShutdownDelay: -1})
+	m.Health("/health")
+	assert.EqualValues(t, -1, m.shutdownDelay())
+	m = New(nil)
+	assert.EqualValues(t, 0, m.shutdownDelay())
+}
+
+func getHealth(uri string) (int, HealthStatus, error) {
+	var s HealthStatus
+	res, err := http.Get(uri)
+	if err != nil {
+		return 0, s, err
+	}
+	if err := json.NewDecoder(res.Body).Decode(&s); err != nil {
+		return 0, s, err
+	}
+	if err := res.Body.Close(); err != nil {
+		return 0, s, err
+	}
+	return res.StatusCode, s, nil
+}
+
+func TestHealthPaths(t *testing.T) {
+	table := []struct {
+		Purpose, Path string
+		URLs          []string
+	}{
+		{"1. OK: root", "/", []string{"/", "/live", "/ready", "/live/"}},
+		{"2. OK: trailing slash", "/status/", []string{"/status", "/status/", "/status/live", "/status/ready"}},
+	}
+	for _, x := range table {
+		m := New(nil)
+		m.Health(x.Path)
+		ts := httptest.NewServer(m)
+		for _, u := range x.URLs {
+			code, actual, err := getHealth(ts.URL + u)
+			assert.Nil(t, err, x.Purpose+" "+u)
+			assert.EqualValues(t, http.StatusOK, code, x.Purpose+" "+u)
+			assert.EqualValues(t, StatusOK, actual.Status, x.Purpose+" "+u)
+		}
+		ts.Close()
+	}
+}
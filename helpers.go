@@ -16,6 +16,7 @@ var (
 	// DefaultFuncMap can be used with LoadViews for common template tasks like:
 	//	cap: capitalize strings
 	//	eqs: compare value of two types.
+	//	translate: validation errors in a locale, see RegisterCatalog.
 	DefaultFuncMap = deffuncmap()
 )
 
@@ -31,6 +32,7 @@ func deffuncmap() template.FuncMap {
 		"eqs": func(x, y interface{}) bool {
 			return fmt.Sprintf("%v", x) == fmt.Sprintf("%v", y)
 		},
+		"translate": translate,
 	}
 }
 
@@ -69,6 +71,9 @@ func JSON(w http.ResponseWriter, v interface{}) error {
 }
 
 func checkDuplicate(m *SREST, method, uri string) {
+	if m.Map == nil {
+		panic(fmt.Sprintf("definition after start: %s %s", method, uri))
+	}
 	// Validate path vars.
 	s := method + ":" + removeVars(uri)
 	if _, ok := m.Map[s]; ok {
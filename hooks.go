@@ -0,0 +1,116 @@
+package srest
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultHookTimeout is used when a hook is registered with zero timeout.
+	DefaultHookTimeout = 15 * time.Second
+)
+
+// hook is a start or stop function, hooks are kept in registration order.
+type hook struct {
+	timeout     time.Duration
+	start, stop func(context.Context) error
+}
+
+// OnStart registers f to run before Run, RunContext, ListenAndServe and
+// Serve start serving. Hooks run once in registration order, each one with
+// its own timeout. An error aborts the startup and the stop hooks
+// registered before f run in reverse order.
+func (m *SREST) OnStart(timeout time.Duration, f func(context.Context) error) {
+	m.hooks = append(m.hooks, hook{timeout: timeout, start: f})
+}
+
+// OnStop registers f to run once in-flight requests are drained or the
+// shutdown deadline expires. Hooks run in reverse registration order, each
+// one with its own timeout, and their errors are returned by Shutdown. Only
+// the hooks registered before the last start hook that ran are stopped, a
+// server that never started runs none.
+func (m *SREST) OnStop(timeout time.Duration, f func(context.Context) error) {
+	m.hooks = append(m.hooks, hook{timeout: timeout, stop: f})
+}
+
+// OnShutdown registers f to be called once in-flight requests are drained or
+// the shutdown deadline expires. Hooks run in registration order before the
+// stop hooks.
+func (m *SREST) OnShutdown(f func()) {
+	m.onShutdown = append(m.onShutdown, f)
+}
+
+// start runs the start hooks once. On failure the previous stop hooks run.
+func (m *SREST) start(ctx context.Context) error {
+	m.startOnce.Do(func() {
+		for i, h := range m.hooks {
+			if h.start == nil {
+				continue
+			}
+			if err := runHook(ctx, h.timeout, h.start); err != nil {
+				_ = m.stop(ctx, m.hooks[:i])
+				m.startErr = err
+				return
+			}
+		}
+		m.started = len(m.hooks)
+	})
+	return m.startErr
+}
+
+// stopStarted runs the stop hooks of the hooks that started, start hooks
+// can't run after it.
+func (m *SREST) stopStarted(ctx context.Context) error {
+	m.startOnce.Do(func() {})
+	return m.stop(ctx, m.hooks[:m.started])
+}
+
+// stop runs the OnShutdown hooks and the stop hooks of hs in reverse order.
+// It only runs once, later calls return the first result.
+func (m *SREST) stop(ctx context.Context, hs []hook) error {
+	m.hooksOnce.Do(func() {
+		// Stop hooks get their own deadline even if ctx is done.
+		ctx = context.WithoutCancel(ctx)
+		var errs []error
+		for _, f := range m.onShutdown {
+			f := f
+			if err := runHook(ctx, 0, func(context.Context) error {
+				f()
+				return nil
+			}); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for i := len(hs) - 1; i >= 0; i-- {
+			if hs[i].stop == nil {
+				continue
+			}
+			if err := runHook(ctx, hs[i].timeout, hs[i].stop); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		m.hooksErr = errors.Join(errs...)
+	})
+	return m.hooksErr
+}
+
+// runHook calls f with timeout, hooks that ignore ctx can't block.
+func runHook(ctx context.Context, timeout time.Duration, f func(context.Context) error) error {
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- f(ctx)
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
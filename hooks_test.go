@@ -0,0 +1,125 @@
+package srest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks(t *testing.T) {
+	var calls []string
+	hookf := func(name string, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+	table := []struct {
+		Purpose  string
+		Hooks    func(m *SREST)
+		Cancel   bool
+		ExpErr   string
+		ExpCalls []string
+	}{
+		{
+			"1. OK: start and reverse stop",
+			func(m *SREST) {
+				m.OnStart(0, hookf("start db", nil))
+				m.OnStop(0, hookf("stop db", nil))
+				m.OnStart(0, hookf("start worker", nil))
+				m.OnStop(0, hookf("stop worker", nil))
+			},
+			true,
+			"<nil>",
+			[]string{"start db", "start worker", "stop worker", "stop db"},
+		},
+		{
+			"2. Fail: start aborts and tears down previous hooks",
+			func(m *SREST) {
+				m.OnStart(0, hookf("start db", nil))
+				m.OnStop(0, hookf("stop db", nil))
+				m.OnStart(0, hookf("start worker", errors.New("worker failed")))
+				m.OnStop(0, hookf("stop worker", nil))
+			},
+			false,
+			"worker failed",
+			[]string{"start db", "start worker", "stop db"},
+		},
+		{
+			"3. Fail: start timeout",
+			func(m *SREST) {
+				m.OnStart(10*time.Millisecond, func(ctx context.Context) error {
+					<-time.After(time.Second)
+					return nil
+				})
+			},
+			false,
+			"context deadline exceeded",
+			nil,
+		},
+		{
+			"4. Fail: stop errors",
+			func(m *SREST) {
+				m.OnStop(0, hookf("stop a", errors.New("a failed")))
+				m.OnStop(0, hookf("stop b", errors.New("b failed")))
+			},
+			true,
+			"b failed\na failed",
+			[]string{"stop b", "stop a"},
+		},
+	}
+	for _, x := range table {
+		calls = nil
+		m := New(nil)
+		x.Hooks(m)
+		ctx, cancel := context.WithCancel(context.Background())
+		if x.Cancel {
+			go func() {
+				<-time.After(50 * time.Millisecond)
+				cancel()
+			}()
+		}
+		err := m.RunContext(ctx, 9014)
+		cancel()
+		assert.EqualValues(t, x.ExpErr, fmt.Sprintf("%v", err), x.Purpose)
+		assert.EqualValues(t, x.ExpCalls, calls, x.Purpose)
+	}
+}
+
+func TestHooksServe(t *testing.T) {
+	var calls []string
+	hookf := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	// Shutdown without serving runs only OnShutdown hooks.
+	m := New(nil)
+	m.OnStart(0, hookf("start db"))
+	m.OnStop(0, hookf("stop db"))
+	m.OnShutdown(func() { calls = append(calls, "shutdown") })
+	err := m.Shutdown(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"shutdown"}, calls)
+
+	// ListenAndServe runs start hooks.
+	calls = nil
+	m = New(nil)
+	m.OnStart(0, hookf("start db"))
+	m.OnStop(0, hookf("stop db"))
+	done := make(chan error, 1)
+	go func() {
+		done <- m.ListenAndServe(":9014")
+	}()
+	<-time.After(100 * time.Millisecond)
+	err = m.Shutdown(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, <-done)
+	assert.EqualValues(t, []string{"start db", "stop db"}, calls)
+}
@@ -0,0 +1,176 @@
+package srest
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the language of the built-in messages and RegisterMessage.
+const DefaultLocale = "en"
+
+// catalogs by locale, guarded by mmut like messages.
+var catalogs = map[string]map[string]string{}
+
+// localeKey is the context key of WithLocale.
+type localeKey struct{}
+
+// RegisterCatalog adds or replaces the messages of locale keyed by rule, like
+// RegisterMessage, e.g.:
+//
+//	srest.RegisterCatalog("es", map[string]string{
+//		"required": "{field} es obligatorio",
+//	})
+//
+// Locales are language tags like "es" or "es-MX", the latter falls back to
+// "es" and then to DefaultLocale messages.
+func RegisterCatalog(locale string, msgs map[string]string) {
+	mmut.Lock()
+	defer mmut.Unlock()
+	c := make(map[string]string, len(msgs))
+	for k, v := range msgs {
+		c[k] = v
+	}
+	catalogs[normLocale(locale)] = c
+}
+
+// WithLocale returns ctx with an explicit locale, it takes precedence over
+// Accept-Language, e.g. from a user profile.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, normLocale(locale))
+}
+
+// Locale returns the locale of r set by WithLocale or else the first
+// language of Accept-Language, by quality, with a catalog. It returns
+// DefaultLocale when none matches.
+func Locale(r *http.Request) string {
+	if l, ok := r.Context().Value(localeKey{}).(string); ok {
+		return l
+	}
+	type lang struct {
+		tag string
+		q   float64
+	}
+	var langs []lang
+	for _, x := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		parts := strings.Split(x, ";")
+		tag := normLocale(parts[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			langs = append(langs, lang{tag, q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	mmut.RLock()
+	defer mmut.RUnlock()
+	for _, l := range langs {
+		for _, tag := range []string{l.tag, baseLocale(l.tag)} {
+			if _, ok := catalogs[tag]; ok {
+				return tag
+			}
+			if tag == DefaultLocale {
+				return tag
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// Translate returns a copy of e with the message of locale, the message is
+// kept when no catalog has the rule.
+func (e *FieldError) Translate(locale string) *FieldError {
+	c := *e
+	if msg, ok := message(locale, e.Rule); ok {
+		c.Message = formatMessage(msg, e.Field, strings.Join(e.Params, " "))
+	}
+	return &c
+}
+
+// Translate returns a copy of e with the messages of locale.
+func (e ValidationErrors) Translate(locale string) ValidationErrors {
+	res := make(ValidationErrors, len(e))
+	for i := range e {
+		res[i] = e[i].Translate(locale)
+	}
+	return res
+}
+
+// translate is the template func of DefaultFuncMap, err is returned as
+// ValidationErrors so templates can range over it. err is an interface{},
+// templates can't pass nil as error.
+func translate(locale string, err interface{}) ValidationErrors {
+	switch x := err.(type) {
+	case ValidationErrors:
+		return x.Translate(locale)
+	case *FieldError:
+		return ValidationErrors{x.Translate(locale)}
+	case error:
+		return ValidationErrors{{Message: x.Error()}}
+	}
+	return nil
+}
+
+// localize translates validation errors to locale, other errors and
+// DefaultLocale errors are returned as is.
+func localize(locale string, err error) error {
+	if locale == DefaultLocale {
+		return err
+	}
+	switch x := err.(type) {
+	case ValidationErrors:
+		return x.Translate(locale)
+	case *FieldError:
+		return x.Translate(locale)
+	}
+	return err
+}
+
+// contextLocale returns the locale of WithLocale or DefaultLocale.
+func contextLocale(ctx context.Context) string {
+	if l, ok := ctx.Value(localeKey{}).(string); ok {
+		return l
+	}
+	return DefaultLocale
+}
+
+// message returns the message of rule for locale, its base language or
+// DefaultLocale.
+func message(locale, rule string) (string, bool) {
+	mmut.RLock()
+	defer mmut.RUnlock()
+	locale = normLocale(locale)
+	for _, tag := range []string{locale, baseLocale(locale)} {
+		if msg, ok := catalogs[tag][rule]; ok {
+			return msg, true
+		}
+	}
+	msg, ok := messages[rule]
+	return msg, ok
+}
+
+// normLocale lowercases locale and uses "-" as separator, e.g. "es-mx".
+func normLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+}
+
+// baseLocale returns the language of locale, e.g. "es" for "es-mx".
+func baseLocale(locale string) string {
+	if i := strings.Index(locale, "-"); i > -1 {
+		return locale[:i]
+	}
+	return locale
+}
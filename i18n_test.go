@@ -0,0 +1,151 @@
+package srest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	RegisterCatalog("es", map[string]string{
+		"required": "{field} es obligatorio",
+		"email":    "{field} debe ser un correo válido",
+		"oneof":    "{field} debe ser uno de {param}",
+		"reserved": "{field} está reservado",
+	})
+	RegisterCatalog("es_MX", map[string]string{
+		"required": "{field} es requerido",
+	})
+}
+
+// ReservedModel struct returns a translatable error from IsValid.
+type ReservedModel struct {
+	Name string `schema:"name" validate:"required"`
+}
+
+// IsValid modeler interface
+func (m *ReservedModel) IsValid() error {
+	if m.Name == "root" {
+		return NewFieldError("name", "reserved", "")
+	}
+	return nil
+}
+
+func TestLocale(t *testing.T) {
+	table := []struct {
+		Purpose        string
+		AcceptLanguage string
+		Locale         string
+		Exp            string
+	}{
+		{"1. OK: empty", "", "", "en"},
+		{"2. OK: exact", "es-MX,es;q=0.9", "", "es-mx"},
+		{"3. OK: base language", "es-AR", "", "es"},
+		{"4. OK: quality", "fr;q=0.9, en;q=0.5, es;q=0.8", "", "es"},
+		{"5. OK: default before catalog", "en-US,es;q=0.5", "", "en"},
+		{"6. OK: no catalog", "fr, de", "", "en"},
+		{"7. OK: zero quality", "es;q=0, *", "", "en"},
+		{"8. OK: explicit", "es", "es_MX", "es-mx"},
+	}
+	for _, x := range table {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Language", x.AcceptLanguage)
+		if x.Locale != "" {
+			r = r.WithContext(WithLocale(r.Context(), x.Locale))
+		}
+		assert.Equal(t, x.Exp, Locale(r), x.Purpose)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	errs := ValidationErrors{
+		NewFieldError("name", "required", ""),
+		NewFieldError("role", "oneof", "admin user"),
+		NewFieldError("age", "min", "18"),
+		{Field: "x", Rule: "custom", Message: "x is custom"},
+	}
+	table := []struct {
+		Purpose string
+		Locale  string
+		Exp     string
+	}{
+		{"1. OK: default", "en", "srest: validation failed: name is required; role must be one of admin user; age must be at least 18; x is custom"},
+		{"2. OK: catalog", "es", "srest: validation failed: name es obligatorio; role debe ser uno de admin user; age must be at least 18; x is custom"},
+		{"3. OK: regional catalog", "es-MX", "srest: validation failed: name es requerido; role debe ser uno de admin user; age must be at least 18; x is custom"},
+		{"4. OK: unknown locale", "fr", "srest: validation failed: name is required; role must be one of admin user; age must be at least 18; x is custom"},
+	}
+	for _, x := range table {
+		assert.EqualError(t, errs.Translate(x.Locale), x.Exp, x.Purpose)
+	}
+	assert.Equal(t, "name is required", errs[0].Message, "translate must not modify errs")
+}
+
+func TestBindLocale(t *testing.T) {
+	table := []struct {
+		Purpose        string
+		AcceptLanguage string
+		Values         url.Values
+		ExpErr         string
+	}{
+		{"1. OK", "es", url.Values{"name": {"x"}}, ""},
+		{"2. Fail: validation", "es", url.Values{}, "srest: validation failed: name es obligatorio"},
+		{"3. Fail: IsValid", "es", url.Values{"name": {"root"}}, "name está reservado"},
+		{"4. Fail: default locale", "", url.Values{"name": {"root"}}, "name is invalid"},
+	}
+	for _, x := range table {
+		r := httptest.NewRequest("GET", "/?"+x.Values.Encode(), nil)
+		r.Header.Set("Accept-Language", x.AcceptLanguage)
+		var m ReservedModel
+		err := BindRequest(r, &m)
+		if x.ExpErr == "" {
+			assert.Nil(t, err, x.Purpose)
+			continue
+		}
+		assert.EqualError(t, err, x.ExpErr, x.Purpose)
+	}
+}
+
+func TestBindContextLocale(t *testing.T) {
+	var m FormModel
+	err := BindContext(WithLocale(context.Background(), "es"), url.Values{}, &m)
+	assert.EqualError(t, err, "srest: validation failed: Name es obligatorio")
+}
+
+func TestBindsLocale(t *testing.T) {
+	h := Binds[CreateUser]()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest("POST", "/users", strings.NewReader(`{"email":"x"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept-Language", "es-MX")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, 422, w.Code)
+	assert.Equal(t, `{"errors":[{"field":"name","rule":"required","message":"name es requerido"},{"field":"email","rule":"email","message":"email debe ser un correo válido"}]}`, strings.TrimSpace(w.Body.String()))
+}
+
+func TestTranslateFunc(t *testing.T) {
+	tpl := template.Must(template.New("x").Funcs(DefaultFuncMap).Parse(`{{ range translate .Locale .Err }}<li>{{ .Message }}</li>{{ end }}`))
+	table := []struct {
+		Purpose string
+		Err     error
+		Exp     string
+	}{
+		{"1. OK: nil", nil, ""},
+		{"2. OK: validation errors", ValidationErrors{NewFieldError("name", "required", "")}, "<li>name es obligatorio</li>"},
+		{"3. OK: field error", NewFieldError("email", "email", ""), "<li>email debe ser un correo válido</li>"},
+		{"4. OK: other error", errors.New("expected fail"), "<li>expected fail</li>"},
+	}
+	for _, x := range table {
+		var buf bytes.Buffer
+		err := tpl.Execute(&buf, map[string]interface{}{"Locale": "es", "Err": x.Err})
+		assert.Nil(t, err, x.Purpose)
+		assert.Equal(t, x.Exp, buf.String(), x.Purpose)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !plan9
+
+package srest
+
+import "syscall"
+
+// errAddrInUse is returned by listen for Unix sockets in use.
+var errAddrInUse error = syscall.EADDRINUSE
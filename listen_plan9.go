@@ -0,0 +1,7 @@
+package srest
+
+import "errors"
+
+// errAddrInUse is returned by listen for Unix sockets in use, Plan 9 has no
+// errno for it.
+var errAddrInUse = errors.New("address already in use")
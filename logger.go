@@ -0,0 +1,134 @@
+package srest
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Logger interface is satisfied by *slog.Logger. Arguments after msg are
+// key-value pairs like slog.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+var (
+	// logger used by package functions like Render and by SREST when
+	// Options.Logger is nil.
+	logger Logger = slog.Default()
+	lmut   sync.RWMutex
+)
+
+// SetLogger sets the package logger. Default is slog.Default.
+func SetLogger(l Logger) {
+	lmut.Lock()
+	logger = l
+	lmut.Unlock()
+}
+
+// pkgLogger returns the package logger.
+func pkgLogger() Logger {
+	lmut.RLock()
+	defer lmut.RUnlock()
+	return logger
+}
+
+// log returns Options.Logger or the package logger.
+func (m *SREST) log() Logger {
+	if m.Options.Logger != nil {
+		return m.Options.Logger
+	}
+	return pkgLogger()
+}
+
+// routeKey is the context key of the route logged by accessLog.
+type routeKey struct{}
+
+// accessLog logs every request handled by h with method, route, status and
+// duration. Route is empty for requests that don't match one, like 404s.
+func (m *SREST) accessLog(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		var route string
+		h.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), routeKey{}, &route)))
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		m.log().Info("request",
+			"method", r.Method,
+			"route", route,
+			"path", r.URL.Path,
+			"status", sw.status,
+			"duration", time.Since(start),
+		)
+	})
+}
+
+// logRoute sets the route logged by accessLog.
+func logRoute(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := r.Context().Value(routeKey{}).(*string); ok {
+			*p = route
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// statusWriter keeps the response status code.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements io.Writer.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher when the original writer does, e.g. for
+// server-sent events.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, e.g. for websockets. It fails when the
+// original writer can't be hijacked.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap allows http.ResponseController to reach the original writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
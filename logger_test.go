@@ -0,0 +1,189 @@
+package srest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// logBuffer is a concurrent safe buffer for JSON logs.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write implements io.Writer.
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// Entries returns the decoded log lines.
+func (b *logBuffer) Entries() ([]map[string]interface{}, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var res []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var x map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &x); err != nil {
+			return nil, err
+		}
+		delete(x, "time")
+		delete(x, "duration")
+		res = append(res, x)
+	}
+	return res, nil
+}
+
+func TestLogger(t *testing.T) {
+	var buf logBuffer
+	l := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	m := New(&Options{Logger: l, AccessLog: true})
+	m.Post("/me/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	ts := httptest.NewServer(m)
+	defer ts.Close()
+
+	res, err := http.Post(ts.URL+"/me/2", "text/plain", nil)
+	assert.Nil(t, err)
+	err = res.Body.Close()
+	assert.Nil(t, err)
+	for _, u := range []string{"/me/2", "/nope"} {
+		res, err = http.Get(ts.URL + u)
+		assert.Nil(t, err)
+		err = res.Body.Close()
+		assert.Nil(t, err)
+	}
+
+	actual, err := buf.Entries()
+	assert.Nil(t, err)
+	expected := []map[string]interface{}{
+		{"level": "DEBUG", "msg": "register", "method": "POST", "route": "/me/:id/"},
+		{"level": "DEBUG", "msg": "register", "method": "POST", "route": "/me/:id"},
+		{"level": "INFO", "msg": "request", "method": "POST", "route": "/me/:id", "path": "/me/2", "status": float64(201)},
+		{"level": "INFO", "msg": "request", "method": "GET", "route": "", "path": "/me/2", "status": float64(405)},
+		{"level": "INFO", "msg": "request", "method": "GET", "route": "", "path": "/nope", "status": float64(404)},
+	}
+	assert.EqualValues(t, expected, actual)
+}
+
+func TestAccessLogRedirect(t *testing.T) {
+	var buf logBuffer
+	l := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	m := New(&Options{Logger: l, AccessLog: true, RedirectHTTP: "127.0.0.1:0"})
+	ls, err := m.listenAll(-1)
+	assert.Nil(t, err)
+	closeAll(ls)
+
+	w := httptest.NewRecorder()
+	m.redirect.Handler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/a", nil))
+	assert.EqualValues(t, http.StatusMovedPermanently, w.Code)
+
+	actual, err := buf.Entries()
+	assert.Nil(t, err)
+	expected := []map[string]interface{}{
+		{"level": "INFO", "msg": "request", "method": "GET", "route": "", "path": "/a", "status": float64(301)},
+	}
+	assert.EqualValues(t, expected, actual)
+}
+
+func TestLoggerRender(t *testing.T) {
+	var buf logBuffer
+	SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer SetLogger(slog.Default())
+
+	dir, err := getTempDir()
+	assert.Nil(t, err)
+	err = LoadViews(dir+"/a", DefaultFuncMap)
+	assert.Nil(t, err)
+	Debug(false)
+
+	w := httptest.NewRecorder()
+	err = Render(w, "notfound.html", nil)
+	assert.EqualValues(t, ErrTemplateNotFound, err)
+
+	actual, err := buf.Entries()
+	assert.Nil(t, err)
+	expected := []map[string]interface{}{
+		{"level": "ERROR", "msg": "render: template not found", "template": "notfound.html"},
+	}
+	assert.EqualValues(t, expected, actual)
+}
+
+func TestAccessLogWriter(t *testing.T) {
+	var buf logBuffer
+	l := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	m := New(&Options{Logger: l, AccessLog: true})
+	m.Get("/events", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "flusher not found", http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintln(w, "data: x")
+		f.Flush()
+	}))
+	m.Get("/ws", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "hijacker not found", http.StatusInternalServerError)
+			return
+		}
+		conn, rw, err := h.Hijack()
+		if err != nil {
+			return
+		}
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 7\r\nConnection: close\r\n\r\nhijack\n")
+		_ = rw.Flush()
+		_ = conn.Close()
+	}))
+	ts := httptest.NewServer(m)
+	defer ts.Close()
+
+	table := []struct {
+		Purpose string
+		Path    string
+		Exp     string
+	}{
+		{"1. OK: flusher", "/events", "data: x"},
+		{"2. OK: hijacker", "/ws", "hijack"},
+	}
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	for _, x := range table {
+		actual, err := getBody(client, "GET", ts.URL+x.Path)
+		assert.Nil(t, err, x.Purpose)
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+	}
+
+	var statuses []interface{}
+	entries, err := buf.Entries()
+	assert.Nil(t, err)
+	for _, e := range entries {
+		if e["msg"] == "request" {
+			statuses = append(statuses, e["status"])
+		}
+	}
+	assert.EqualValues(t, []interface{}{float64(200), float64(101)}, statuses)
+
+	// Writers without those interfaces fail on use.
+	sw := &statusWriter{ResponseWriter: struct{ http.ResponseWriter }{httptest.NewRecorder()}}
+	sw.Flush()
+	_, _, err = sw.Hijack()
+	assert.Equal(t, http.ErrNotSupported, err)
+}
@@ -6,16 +6,25 @@
 package srest
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"runtime"
 	"runtime/pprof"
 	"testing"
+	"time"
 )
 
 func TestMain(m *testing.M) {
+	if os.Getenv(envUpgradeChild) != "" {
+		upgradeChild()
+		return
+	}
+
 	// Create temporal dir and templates.
 	if err := doTempViews(); err != nil {
 		panic(err)
@@ -40,6 +49,34 @@ const (
 	tmpDirName = "_tmp_views"
 )
 
+const (
+	// envUpgradeChild makes TestMain run upgradeChild instead of tests.
+	envUpgradeChild = "SREST_TEST_UPGRADE_CHILD"
+)
+
+// upgradeChild is the process started by TestUpgrade, it serves one
+// request on the inherited listener. With "exit" it fails before serving
+// and with "hang" it never gets ready, see TestUpgradeFail.
+func upgradeChild() {
+	switch os.Getenv(envUpgradeChild) {
+	case "exit":
+		os.Exit(1)
+	case "hang":
+		time.Sleep(time.Minute)
+		os.Exit(1)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m := New(nil)
+	m.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, "child")
+		cancel()
+	}))
+	if err := m.RunContext(ctx, 9013); err != nil {
+		panic(err)
+	}
+}
+
 // getTempDir returns the temporal dir for templates tests.
 func getTempDir() (string, error) {
 	dir, err := os.Getwd()
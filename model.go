@@ -0,0 +1,96 @@
+package srest
+
+import (
+	"context"
+	"net/http"
+)
+
+// modelKey is the context key of models of type T.
+type modelKey[T any] struct{}
+
+// Binds returns a middleware that binds every request into a new T with
+// DefaultBinder.BindRequest and stores it in the request context, see Model.
+// T must be a struct. Requests that fail are answered without calling the
+// next handler:
+//
+//	415	ErrUnsupportedMediaType
+//	413	ErrBodyTooLarge
+//	400	ErrEmptyBody, JSONError or RequestError
+//	422	ValidationErrors or *FieldError, see WriteValidationErrors
+//	500	any other error, like unknown rules, invalid file tags or
+//		failures of ValidContext
+//
+// IsValid should return NewFieldError for invalid values, other errors are
+// logged with the package logger and not sent to the client.
+//
+// Usage:
+// m.Post("/users", http.HandlerFunc(createUser), srest.Binds[CreateUser]())
+func Binds[T any]() func(http.Handler) http.Handler {
+	return BindsWith[T](nil)
+}
+
+// BindsWith is like Binds with b, nil means DefaultBinder.
+func BindsWith[T any](b *Binder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			binder := b
+			if binder == nil {
+				binder = DefaultBinder
+			}
+			v := new(T)
+			errs, err := binder.decodeRequest(r, v)
+			if err != nil {
+				writeBindError(w, r, err)
+				return
+			}
+			err = localize(Locale(r), isValid(binder.context(r.Context()), v, errs, binder.options.TagName))
+			if err != nil {
+				writeValidError(w, r, err)
+				return
+			}
+			ctx := context.WithValue(r.Context(), modelKey[T]{}, v)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Model returns the model stored by Binds[T] or nil if the request wasn't
+// bound to T.
+func Model[T any](r *http.Request) *T {
+	v, _ := r.Context().Value(modelKey[T]{}).(*T)
+	return v
+}
+
+// writeBindError answers a request that can't be decoded, errors that
+// aren't caused by the request are logged.
+func writeBindError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case err == ErrUnsupportedMediaType:
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case err == ErrBodyTooLarge:
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case clientError(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeInternalError(w, r, err)
+	}
+}
+
+// writeValidError answers a request with an invalid model, errors that
+// aren't validation errors are logged.
+func writeValidError(w http.ResponseWriter, r *http.Request, err error) {
+	switch x := err.(type) {
+	case ValidationErrors:
+		_ = WriteValidationErrors(w, x)
+	case *FieldError:
+		_ = WriteValidationErrors(w, ValidationErrors{x})
+	default:
+		writeInternalError(w, r, err)
+	}
+}
+
+// writeInternalError logs err and answers with a generic 500.
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	pkgLogger().Error("bind", "method", r.Method, "path", r.URL.Path, "err", err)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
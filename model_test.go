@@ -0,0 +1,134 @@
+package srest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// CreateUser struct is bound by Binds.
+type CreateUser struct {
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"email"`
+}
+
+// IsValid modeler interface
+func (u *CreateUser) IsValid() error {
+	if u.Name == "root" {
+		return NewFieldError("name", "reserved", "")
+	}
+	return nil
+}
+
+func TestBinds(t *testing.T) {
+	table := []struct {
+		Purpose     string
+		ContentType string
+		Body        string
+		ExpCode     int
+		ExpBody     string
+	}{
+		{"1. OK", "application/json", `{"name":"x","email":"x@y.com"}`, 200, "x x@y.com"},
+		{"2. Fail: validation", "application/json", `{"email":"x"}`, 422, `{"errors":[{"field":"name","rule":"required","message":"name is required"},{"field":"email","rule":"email","message":"email must be a valid email address"}]}`},
+		{"3. Fail: IsValid", "application/json", `{"name":"root","email":"x@y.com"}`, 422, `{"errors":[{"field":"name","rule":"reserved","message":"name is invalid"}]}`},
+		{"4. Fail: syntax", "application/json", `{"name":}`, 400, "srest: invalid JSON at offset 9: invalid character '}' looking for beginning of value"},
+		{"5. Fail: content type", "text/plain", `x`, 415, "srest: unsupported media type"},
+		{"6. Fail: malformed XML", "application/xml", `<m><name>`, 400, "srest: malformed request: XML syntax error on line 1: unexpected EOF"},
+		{"7. Fail: empty body", "application/json", ``, 400, "srest: empty request body"},
+	}
+	for _, x := range table {
+		h := Binds[CreateUser]()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u := Model[CreateUser](r)
+			fmt.Fprintln(w, u.Name, u.Email)
+		}))
+		r := httptest.NewRequest("POST", "/users", strings.NewReader(x.Body))
+		r.Header.Set("Content-Type", x.ContentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, x.ExpCode, w.Code, x.Purpose)
+		assert.Equal(t, x.ExpBody, strings.TrimSpace(w.Body.String()), x.Purpose)
+	}
+}
+
+func TestBindsWith(t *testing.T) {
+	b := NewBinder(&BinderOptions{JSONBodyLimit: 5})
+	var called bool
+	h := BindsWith[CreateUser](b)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	r := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"x"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.False(t, called)
+}
+
+func TestModel(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.Nil(t, Model[CreateUser](r))
+}
+
+// DialModel struct fails with an infrastructure error.
+type DialModel struct {
+	Name string `json:"name"`
+}
+
+// ValidContext context modeler interface
+func (m *DialModel) ValidContext(ctx context.Context) error {
+	return errors.New("dial tcp 10.0.0.5:5432: connection refused")
+}
+
+// RuleModel struct has an unknown rule.
+type RuleModel struct {
+	Name string `json:"name" validate:"unknown"`
+}
+
+// FileModel struct has an invalid file field.
+type FileModel struct {
+	Name string `json:"name"`
+	File string `file:"file"`
+}
+
+func TestBindsInternalError(t *testing.T) {
+	var buf logBuffer
+	SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer SetLogger(slog.Default())
+
+	table := []struct {
+		Purpose string
+		Binds   func(http.Handler) http.Handler
+		ExpErr  string
+	}{
+		{"1. Fail: ValidContext", Binds[DialModel](), "dial tcp 10.0.0.5:5432: connection refused"},
+		{"2. Fail: unknown rule", Binds[RuleModel](), `srest: unknown validation rule "unknown" on field name`},
+		{"3. Fail: invalid file field", Binds[FileModel](), "srest: file field File must be *multipart.FileHeader or []*multipart.FileHeader"},
+	}
+	for _, x := range table {
+		h := x.Binds(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("handler must not run")
+		}))
+		r := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"x"}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusInternalServerError, w.Code, x.Purpose)
+		assert.Equal(t, "Internal Server Error", strings.TrimSpace(w.Body.String()), x.Purpose)
+	}
+
+	actual, err := buf.Entries()
+	assert.Nil(t, err)
+	expected := []map[string]interface{}{
+		{"level": "ERROR", "msg": "bind", "method": "POST", "path": "/users", "err": table[0].ExpErr},
+		{"level": "ERROR", "msg": "bind", "method": "POST", "path": "/users", "err": table[1].ExpErr},
+		{"level": "ERROR", "msg": "bind", "method": "POST", "path": "/users", "err": table[2].ExpErr},
+	}
+	assert.EqualValues(t, expected, actual)
+}
@@ -0,0 +1,206 @@
+package srest
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ModFunc type normalizes a string value, see RegisterModifier.
+type ModFunc func(s string) string
+
+var (
+	modifiers = map[string]ModFunc{
+		"trim":  strings.TrimSpace,
+		"ltrim": func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) },
+		"rtrim": func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) },
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"squish": func(s string) string {
+			return strings.Join(strings.Fields(s), " ")
+		},
+	}
+	modmut sync.RWMutex
+)
+
+// RegisterModifier adds or replaces the modifier name.
+func RegisterModifier(name string, f ModFunc) {
+	modmut.Lock()
+	defer modmut.Unlock()
+	modifiers[name] = f
+}
+
+// Modify sets the `default` and `mod` tags of the struct v points to,
+// nested structs and slices of structs are modified too. Every Bind
+// function calls it after decoding and before validation, e.g.:
+//
+//	Limit int    `schema:"limit" default:"20"`
+//	Email string `schema:"email" mod:"trim,lower"`
+//
+// Defaults are set on zero fields of strings, bools, numbers, durations and
+// pointers to them. Modifiers run from left to right on strings, *string
+// and []string fields, built-in modifiers are:
+//
+//	trim, ltrim, rtrim	remove spaces
+//	lower, upper		change case
+//	squish			trim and collapse inner spaces
+//
+// See RegisterModifier to add modifiers. Unknown modifiers and invalid
+// defaults are returned as error.
+func Modify(v interface{}) error {
+	return modify(v, "schema")
+}
+
+// modify uses tag for field paths in errors.
+func modify(v interface{}, tag string) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct || !rv.CanSet() {
+		return nil
+	}
+	return modifyStruct(rv, "", tag)
+}
+
+func modifyStruct(rv reflect.Value, prefix, tag string) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		f := rv.Field(i)
+		name := prefix + fieldName(sf, tag)
+		if def, ok := sf.Tag.Lookup("default"); ok && f.IsZero() {
+			if err := setDefault(f, def); err != nil {
+				return fmt.Errorf("srest: invalid default %q on field %s: %s", def, name, err)
+			}
+		}
+		if mods := sf.Tag.Get("mod"); mods != "" {
+			if err := modifyField(f, mods, name); err != nil {
+				return err
+			}
+		}
+		if err := modifyNested(f, name, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modifyNested modifies structs inside v.
+func modifyNested(v reflect.Value, name, tag string) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return modifyNested(v.Elem(), name, tag)
+	case reflect.Struct:
+		if !v.CanSet() {
+			return nil
+		}
+		return modifyStruct(v, name+".", tag)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := modifyNested(v.Index(i), fmt.Sprintf("%s[%d]", name, i), tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// modifyField runs the modifiers of mods on the strings of f.
+func modifyField(f reflect.Value, mods, name string) error {
+	var fns []ModFunc
+	for _, x := range strings.Split(mods, ",") {
+		modmut.RLock()
+		fn, ok := modifiers[x]
+		modmut.RUnlock()
+		if !ok {
+			return fmt.Errorf("srest: unknown modifier %q on field %s", x, name)
+		}
+		fns = append(fns, fn)
+	}
+	modifyStrings(f, fns)
+	return nil
+}
+
+func modifyStrings(v reflect.Value, fns []ModFunc) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			modifyStrings(v.Elem(), fns)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			modifyStrings(v.Index(i), fns)
+		}
+	case reflect.String:
+		s := v.String()
+		for _, fn := range fns {
+			s = fn(s)
+		}
+		v.SetString(s)
+	}
+}
+
+// setDefault parses s into f, nil pointers are allocated.
+func setDefault(f reflect.Value, s string) error {
+	if f.Kind() == reflect.Ptr {
+		v := reflect.New(f.Type().Elem())
+		if err := setDefault(v.Elem(), s); err != nil {
+			return err
+		}
+		f.Set(v)
+		return nil
+	}
+	if f.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package srest
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ModAddress struct is nested in ModModel.
+type ModAddress struct {
+	City    string `json:"city" mod:"trim,upper"`
+	Country string `json:"country" default:"MX"`
+}
+
+// ModModel struct has default and mod tags.
+type ModModel struct {
+	Limit   int           `schema:"limit" default:"20" validate:"max=100"`
+	Ratio   float64       `schema:"ratio" default:"0.5"`
+	Active  *bool         `schema:"active" default:"true"`
+	Timeout time.Duration `schema:"timeout" default:"5s"`
+	Email   string        `schema:"email" mod:"trim,lower" validate:"required,email"`
+	Name    *string       `schema:"name" mod:"squish"`
+	Tags    []string      `schema:"tags" mod:"trim,lower"`
+	Home    ModAddress    `json:"home"`
+	Others  []ModAddress  `json:"others"`
+}
+
+func TestModify(t *testing.T) {
+	yes, name := true, "  Jane   Doe "
+	squished := "Jane Doe"
+	table := []struct {
+		Purpose string
+		Input   interface{}
+		Exp     interface{}
+		ExpErr  string
+	}{
+		{
+			"1. OK: defaults and modifiers",
+			&ModModel{Email: " X@Y.COM ", Name: &name, Tags: []string{" A", "b "}, Home: ModAddress{City: " cdmx "}, Others: []ModAddress{{City: "gdl", Country: "US"}}},
+			&ModModel{Limit: 20, Ratio: 0.5, Active: &yes, Timeout: 5 * time.Second, Email: "x@y.com", Name: &squished, Tags: []string{"a", "b"}, Home: ModAddress{City: "CDMX", Country: "MX"}, Others: []ModAddress{{City: "GDL", Country: "US"}}},
+			"",
+		},
+		{
+			"2. OK: values are kept",
+			&ModModel{Limit: 5, Ratio: 1, Timeout: time.Second},
+			&ModModel{Limit: 5, Ratio: 1, Active: &yes, Timeout: time.Second, Home: ModAddress{Country: "MX"}},
+			"",
+		},
+		{
+			"3. OK: not a struct",
+			&name,
+			&name,
+			"",
+		},
+		{
+			"4. Fail: unknown modifier",
+			&struct {
+				Name string `json:"name" mod:"reverse"`
+			}{Name: "x"},
+			nil,
+			`srest: unknown modifier "reverse" on field name`,
+		},
+		{
+			"5. Fail: invalid default",
+			&struct {
+				Limit int `json:"limit" default:"x"`
+			}{},
+			nil,
+			`srest: invalid default "x" on field limit: strconv.ParseInt: parsing "x": invalid syntax`,
+		},
+		{
+			"6. Fail: unsupported default",
+			&struct {
+				Items []int `json:"items" default:"1"`
+			}{},
+			nil,
+			`srest: invalid default "1" on field items: unsupported type []int`,
+		},
+	}
+	for _, x := range table {
+		err := Modify(x.Input)
+		if x.ExpErr != "" {
+			assert.EqualError(t, err, x.ExpErr, x.Purpose)
+			continue
+		}
+		assert.Nil(t, err, x.Purpose)
+		assert.Equal(t, x.Exp, x.Input, x.Purpose)
+	}
+}
+
+func TestRegisterModifier(t *testing.T) {
+	RegisterModifier("nodash", func(s string) string { return strings.Replace(s, "-", "", -1) })
+	v := struct {
+		Phone string `json:"phone" mod:"trim,nodash"`
+	}{" 55-1234-5678 "}
+	assert.Nil(t, Modify(&v))
+	assert.Equal(t, "5512345678", v.Phone)
+}
+
+func TestBindModify(t *testing.T) {
+	table := []struct {
+		Purpose string
+		Values  url.Values
+		Exp     int
+		ExpErr  string
+	}{
+		{"1. OK: modified before validation", url.Values{"email": {" X@Y.COM "}}, 20, ""},
+		{"2. Fail: limit above max", url.Values{"email": {"x@y.com"}, "limit": {"101"}}, 101, "srest: validation failed: limit must be at most 100"},
+	}
+	for _, x := range table {
+		var actual ModModel
+		err := Bind(x.Values, &actual)
+		if x.ExpErr != "" {
+			assert.EqualError(t, err, x.ExpErr, x.Purpose)
+		} else {
+			assert.Nil(t, err, x.Purpose)
+			assert.Equal(t, "x@y.com", actual.Email, x.Purpose)
+		}
+		assert.Equal(t, x.Exp, actual.Limit, x.Purpose)
+	}
+}
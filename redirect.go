@@ -0,0 +1,36 @@
+package srest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// redirectHTTPS redirects plain HTTP requests to the TLS listener. Requests
+// other than GET and HEAD use 308 so clients keep the method and body.
+func (m *SREST) redirectHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if m.tlsPort != "" && m.tlsPort != "443" {
+		host = net.JoinHostPort(host, m.tlsPort)
+	}
+	u := *r.URL
+	u.Scheme = "https"
+	u.Host = host
+	code := http.StatusMovedPermanently
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		code = http.StatusPermanentRedirect
+	}
+	http.Redirect(w, r, u.String(), code)
+}
+
+// hsts returns the Strict-Transport-Security header value.
+func (m *SREST) hsts() string {
+	s := fmt.Sprintf("max-age=%d", int64(m.Options.HSTSMaxAge.Seconds()))
+	if m.Options.HSTSIncludeSubdomains {
+		s += "; includeSubDomains"
+	}
+	return s
+}
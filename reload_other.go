@@ -0,0 +1,8 @@
+//go:build !unix
+
+package srest
+
+import "os"
+
+// reloadSignal is not available on non-Unix systems.
+var reloadSignal os.Signal
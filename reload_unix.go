@@ -0,0 +1,11 @@
+//go:build unix
+
+package srest
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignal reloads the TLS certificates when Options.TLSReload is true.
+var reloadSignal os.Signal = syscall.SIGHUP
@@ -0,0 +1,425 @@
+package srest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Run starts the server with http.ListenAndServe or http.ListenAndServeTLS
+// returns a channel binded it to SIGTERM and SIGINT signal. When a signal
+// is received the server is stopped gracefully and the signal is sent on the
+// channel after the shutdown is complete. Run panics if the port can't be
+// listened, use RunContext or ListenAndServe to get the error instead.
+// Options.Listeners and Options.RedirectHTTP are served too, a negative port
+// serves only those and zero uses Options.Addr when set. With
+// Options.Upgrade SIGUSR2 starts a new process and the signal is sent on the
+// channel once this one is drained.
+func (m *SREST) Run(port int) chan os.Signal {
+	if err := m.registerHandlers(); err != nil {
+		panic(fmt.Sprintf("Run : register handlers : err [%s]", err))
+	}
+	if err := m.start(context.Background()); err != nil {
+		panic(fmt.Sprintf("Run : start hooks : err [%s]", err))
+	}
+	ls, err := m.listenAll(port)
+	if err != nil {
+		_ = m.stop(context.Background(), m.hooks)
+		panic(fmt.Sprintf("Run : listen : err [%s]", err))
+	}
+
+	c := make(chan os.Signal, 1)
+	sig := m.notify()
+	errc := m.serveAll(ls)
+	if err := notifyReady(); err != nil {
+		m.log().Error("upgrade: notify ready", "err", err)
+	}
+	go func() {
+		for range ls {
+			if err := <-errc; err != nil {
+				m.log().Error("serve", "err", err)
+			}
+		}
+	}()
+	go func() {
+		s := m.waitSignal(sig, ls)
+		signal.Stop(sig)
+		m.log().Info("shutdown", "signal", s)
+		if err := m.shutdown(); err != nil {
+			m.log().Error("shutdown", "err", err)
+		}
+		c <- s
+	}()
+	return c
+}
+
+// RunContext starts the server and blocks until ctx is done or SIGTERM or
+// SIGINT signal is received. Then it stops accepting connections, waits for
+// in-flight requests up to Options.ShutdownTimeout, runs the stop hooks and
+// returns once the server is fully stopped. Start hooks run before serving.
+// Startup failures like a port already in use or a start hook error are
+// returned. Listeners and upgrades are the same as Run.
+func (m *SREST) RunContext(ctx context.Context, port int) error {
+	if err := m.registerHandlers(); err != nil {
+		return err
+	}
+	if err := m.start(ctx); err != nil {
+		return err
+	}
+	ls, err := m.listenAll(port)
+	if err != nil {
+		_ = m.stop(ctx, m.hooks)
+		return err
+	}
+
+	sig := m.notify()
+	defer signal.Stop(sig)
+
+	errc := m.serveAll(ls)
+	if err := notifyReady(); err != nil {
+		m.log().Error("upgrade: notify ready", "err", err)
+	}
+	stop := make(chan os.Signal, 1)
+	go func() {
+		stop <- m.waitSignal(sig, ls)
+	}()
+	select {
+	case err := <-errc:
+		if err != nil {
+			_ = m.shutdown()
+			return err
+		}
+	case <-ctx.Done():
+	case <-stop:
+	}
+	return m.shutdown()
+}
+
+// notify returns the channel for stop and upgrade signals.
+func (m *SREST) notify() chan os.Signal {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	if m.Options.Upgrade && upgradeSignal != nil {
+		signal.Notify(sig, upgradeSignal)
+	}
+	return sig
+}
+
+// waitSignal returns the signal that stops the server. Upgrade signals
+// start a new process with the listeners, on failure the server keeps
+// running. It returns nil when the server is shut down by other means.
+func (m *SREST) waitSignal(sig chan os.Signal, ls []listener) os.Signal {
+	for {
+		select {
+		case <-m.done:
+			return nil
+		case s := <-sig:
+			if s != upgradeSignal {
+				return s
+			}
+			if err := upgrade(ls, timeout(m.Options.UpgradeTimeout, DefaultUpgradeTimeout)); err != nil {
+				m.log().Error("upgrade", "err", err)
+				continue
+			}
+			m.log().Info("upgrade: new process started")
+			return s
+		}
+	}
+}
+
+// ListenAndServe listens on addr and serves until Shutdown is called, which
+// makes it return nil. Addresses prefixed with "unix:" listen on a Unix
+// socket, e.g. "unix:/var/run/app.sock".
+func (m *SREST) ListenAndServe(addr string) error {
+	if err := m.registerHandlers(); err != nil {
+		return err
+	}
+	if err := m.start(context.Background()); err != nil {
+		return err
+	}
+	l, err := listen(addr)
+	if err != nil {
+		_ = m.stopStarted(context.Background())
+		return err
+	}
+	return m.serve(l)
+}
+
+// Serve accepts connections on l until Shutdown is called, which makes it
+// return nil. TLS is used when Options.UseTLS is true.
+func (m *SREST) Serve(l net.Listener) error {
+	if err := m.registerHandlers(); err != nil {
+		return err
+	}
+	if err := m.start(context.Background()); err != nil {
+		return err
+	}
+	return m.serve(l)
+}
+
+// listener is a bound address and how it must be served.
+type listener struct {
+	net.Listener
+	addr          string
+	tls, redirect bool
+}
+
+// listenAll announces on port, Options.Listeners and Options.RedirectHTTP.
+// On failure the listeners already opened are closed.
+func (m *SREST) listenAll(port int) ([]listener, error) {
+	o := m.Options
+	var addrs []Listener
+	switch {
+	case port == 0 && o.Addr != "":
+		addrs = append(addrs, Listener{Addr: o.Addr, TLS: o.UseTLS})
+	case port >= 0:
+		addrs = append(addrs, Listener{Addr: fmt.Sprintf(":%v", port), TLS: o.UseTLS})
+	}
+	addrs = append(addrs, o.Listeners...)
+
+	// TLS is configured before serving because all listeners share the
+	// http.Server.
+	for _, x := range addrs {
+		if !x.TLS {
+			continue
+		}
+		if err := m.setupTLS(); err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	inherited, err := inheritedListeners()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// Close inherited listeners not declared anymore.
+		for _, l := range inherited {
+			_ = l.Close()
+		}
+	}()
+	bind := func(addr string) (net.Listener, error) {
+		if l, ok := inherited[addr]; ok {
+			delete(inherited, addr)
+			return l, nil
+		}
+		return listen(addr)
+	}
+
+	var ls []listener
+	for _, x := range addrs {
+		l, err := bind(x.Addr)
+		if err != nil {
+			closeAll(ls)
+			return nil, err
+		}
+		ls = append(ls, listener{Listener: l, addr: x.Addr, tls: x.TLS})
+		if x.TLS && m.tlsPort == "" {
+			_, m.tlsPort, _ = net.SplitHostPort(l.Addr().String())
+		}
+	}
+	if o.RedirectHTTP != "" {
+		l, err := bind(o.RedirectHTTP)
+		if err != nil {
+			closeAll(ls)
+			return nil, err
+		}
+		ls = append(ls, listener{Listener: l, addr: o.RedirectHTTP, redirect: true})
+		var h http.Handler = http.HandlerFunc(m.redirectHTTPS)
+		if o.AccessLog {
+			h = m.accessLog(h)
+		}
+		m.redirect = m.newServer(h)
+	}
+	return ls, nil
+}
+
+// serveAll serves every listener in its own goroutine, the returned channel
+// receives the result of each one.
+func (m *SREST) serveAll(ls []listener) chan error {
+	errc := make(chan error, len(ls))
+	for i := range ls {
+		go func(l listener) {
+			switch {
+			case l.redirect:
+				errc <- closed(m.redirect.Serve(l))
+			case l.tls:
+				errc <- m.serveTLS(l)
+			default:
+				errc <- closed(m.httpServer().Serve(l))
+			}
+		}(ls[i])
+	}
+	return errc
+}
+
+func closeAll(ls []listener) {
+	for _, l := range ls {
+		_ = l.Close()
+	}
+}
+
+// Shutdown stops the server gracefully and runs the stop hooks. See
+// http.Server.Shutdown. Readiness endpoints fail during Options.ShutdownDelay
+// before the listeners are closed.
+func (m *SREST) Shutdown(ctx context.Context) error {
+	m.doneOnce.Do(func() {
+		close(m.done)
+	})
+	if d := m.shutdownDelay(); !m.draining.Swap(true) && d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+		}
+	}
+	err := m.httpServer().Shutdown(ctx)
+	if m.redirect != nil {
+		if rerr := m.redirect.Shutdown(ctx); err == nil {
+			err = rerr
+		}
+	}
+	if herr := m.stopStarted(ctx); err == nil {
+		err = herr
+	}
+	return err
+}
+
+// shutdownDelay returns Options.ShutdownDelay or DefaultShutdownDelay when
+// Health is registered.
+func (m *SREST) shutdownDelay() time.Duration {
+	d := m.Options.ShutdownDelay
+	if d == 0 && m.health {
+		d = DefaultShutdownDelay
+	}
+	return d
+}
+
+// shutdown calls Shutdown with Options.ShutdownTimeout deadline.
+func (m *SREST) shutdown() error {
+	d := m.Options.ShutdownTimeout
+	if d <= 0 {
+		d = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return m.Shutdown(ctx)
+}
+
+// serve blocks until the server fails or it's shut down, the latter
+// returns nil.
+func (m *SREST) serve(l net.Listener) error {
+	if m.Options.UseTLS {
+		return m.serveTLS(l)
+	}
+	return closed(m.httpServer().Serve(l))
+}
+
+// serveTLS is like serve with TLS.
+func (m *SREST) serveTLS(l net.Listener) error {
+	if err := m.setupTLS(); err != nil {
+		_ = l.Close()
+		return err
+	}
+	return closed(m.httpServer().ServeTLS(l, "", ""))
+}
+
+// closed returns nil for http.ErrServerClosed.
+func closed(err error) error {
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// httpServer returns the http.Server shared by all listeners.
+func (m *SREST) httpServer() *http.Server {
+	m.once.Do(func() {
+		var h http.Handler = m
+		if m.Options.H2C {
+			// h2c package is used instead of http.Server.Protocols
+			// because it supports the HTTP/1.1 Upgrade too.
+			h = h2c.NewHandler(m, &http2.Server{
+				IdleTimeout: timeout(m.Options.IdleTimeout, DefaultIdleTimeout),
+			})
+		}
+		m.server = m.newServer(h)
+	})
+	return m.server
+}
+
+// newServer returns an http.Server for h configured with Options.
+func (m *SREST) newServer(h http.Handler) *http.Server {
+	o := m.Options
+	srv := &http.Server{
+		Handler:           h,
+		ReadTimeout:       timeout(o.ReadTimeout, DefaultReadTimeout),
+		ReadHeaderTimeout: timeout(o.ReadHeaderTimeout, DefaultReadHeaderTimeout),
+		WriteTimeout:      timeout(o.WriteTimeout, DefaultWriteTimeout),
+		IdleTimeout:       timeout(o.IdleTimeout, DefaultIdleTimeout),
+		MaxHeaderBytes:    o.MaxHeaderBytes,
+		ErrorLog:          o.ErrorLog,
+		ConnState:         o.ConnState,
+	}
+	if srv.MaxHeaderBytes == 0 {
+		srv.MaxHeaderBytes = DefaultMaxHeaderBytes
+	}
+	return srv
+}
+
+// setupTLS configures the server TLS once and starts the certificate
+// watcher when reload is enabled.
+func (m *SREST) setupTLS() error {
+	m.tlsOnce.Do(func() {
+		cfg, err := m.tlsConfig()
+		if err != nil {
+			m.tlsErr = err
+			return
+		}
+		m.httpServer().TLSConfig = cfg
+		if m.Options.TLSReload || m.Options.TLSReloadInterval > 0 {
+			go m.watchTLS(m.done)
+		}
+	})
+	return m.tlsErr
+}
+
+// timeout returns def when d is zero and 0 (no timeout) when d is negative.
+func timeout(d, def time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return def
+	case d < 0:
+		return 0
+	}
+	return d
+}
+
+// listen announces on addr, Unix sockets are prefixed with "unix:". A stale
+// socket file left by a previous process is removed, sockets that accept
+// connections are in use and return an address in use error.
+func listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, "unix:") {
+		return net.Listen("tcp", addr)
+	}
+	name := strings.TrimPrefix(addr, "unix:")
+	if fi, err := os.Stat(name); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if c, err := net.DialTimeout("unix", name, time.Second); err == nil {
+			_ = c.Close()
+			return nil, &net.OpError{Op: "listen", Net: "unix", Addr: &net.UnixAddr{Name: name, Net: "unix"}, Err: errAddrInUse}
+		}
+		if err := os.Remove(name); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", name)
+}
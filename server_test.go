@@ -0,0 +1,260 @@
+package srest
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+)
+
+func TestRunContextGraceful(t *testing.T) {
+	var hooks []string
+	m := New(&Options{ShutdownTimeout: time.Second})
+	m.Get("/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-time.After(300 * time.Millisecond)
+		_, _ = fmt.Fprintln(w, "slow done")
+	}))
+	m.OnShutdown(func() { hooks = append(hooks, "a") })
+	m.OnShutdown(func() { hooks = append(hooks, "b") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.RunContext(ctx, 9003)
+	}()
+	<-time.After(100 * time.Millisecond)
+
+	body := make(chan string, 1)
+	go func() {
+		client := &http.Client{Timeout: time.Second}
+		actual, err := getBody(client, "GET", "http://localhost:9003/slow")
+		assert.Nil(t, err)
+		body <- actual
+	}()
+	<-time.After(100 * time.Millisecond)
+	cancel()
+
+	assert.EqualValues(t, "slow done", <-body)
+	assert.Nil(t, <-done)
+	assert.EqualValues(t, []string{"a", "b"}, hooks)
+
+	// Server must be closed.
+	client := &http.Client{Timeout: 100 * time.Millisecond}
+	_, err := getBody(client, "GET", "http://localhost:9003/slow")
+	assert.NotNil(t, err)
+}
+
+func TestListenFail(t *testing.T) {
+	l, err := net.Listen("tcp", ":9004")
+	assert.Nil(t, err)
+	defer func() {
+		err := l.Close()
+		assert.Nil(t, err)
+	}()
+
+	m := New(nil)
+	err = m.ListenAndServe(":9004")
+	assert.NotNil(t, err)
+
+	err = m.RunContext(context.Background(), 9004)
+	assert.NotNil(t, err)
+
+	defer func() {
+		err := recover()
+		assert.Contains(t, fmt.Sprintf("%s", err), "Run : listen : err [")
+	}()
+	m.Run(9004)
+}
+
+func TestHTTPServerOptions(t *testing.T) {
+	var states []http.ConnState
+	connState := func(c net.Conn, s http.ConnState) {
+		states = append(states, s)
+	}
+	table := []struct {
+		Purpose string
+		Options *Options
+		Exp     [4]time.Duration
+		Bytes   int
+	}{
+		{
+			"1. OK: defaults",
+			nil,
+			[4]time.Duration{DefaultReadTimeout, DefaultReadHeaderTimeout, DefaultWriteTimeout, DefaultIdleTimeout},
+			DefaultMaxHeaderBytes,
+		},
+		{
+			"2. OK: custom",
+			&Options{ReadTimeout: time.Second, ReadHeaderTimeout: 2 * time.Second, WriteTimeout: 3 * time.Second, IdleTimeout: 4 * time.Second, MaxHeaderBytes: 512},
+			[4]time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second},
+			512,
+		},
+		{
+			"3. OK: disabled",
+			&Options{ReadTimeout: -1, WriteTimeout: -1, ConnState: connState},
+			[4]time.Duration{0, DefaultReadHeaderTimeout, 0, DefaultIdleTimeout},
+			DefaultMaxHeaderBytes,
+		},
+	}
+	for _, x := range table {
+		srv := New(x.Options).httpServer()
+		actual := [4]time.Duration{srv.ReadTimeout, srv.ReadHeaderTimeout, srv.WriteTimeout, srv.IdleTimeout}
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+		assert.EqualValues(t, x.Bytes, srv.MaxHeaderBytes, x.Purpose)
+	}
+
+	srv := New(&Options{ConnState: connState}).httpServer()
+	srv.ConnState(nil, http.StateNew)
+	assert.EqualValues(t, []http.ConnState{http.StateNew}, states)
+}
+
+func TestRunContextListeners(t *testing.T) {
+	dir, err := ioutil.TempDir("", "srest")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+	sock := dir + "/srest.sock"
+
+	m := New(&Options{
+		DevCert:    true,
+		DevCertDir: dir,
+		Listeners: []Listener{
+			{Addr: "127.0.0.1:9007", TLS: true},
+			{Addr: "127.0.0.1:9008"},
+			{Addr: "unix:" + sock},
+		},
+		RedirectHTTP:          "127.0.0.1:9009",
+		HSTSMaxAge:            time.Hour,
+		HSTSIncludeSubdomains: true,
+	})
+	m.Get("/", say("multi"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.RunContext(ctx, -1)
+	}()
+	<-time.After(100 * time.Millisecond)
+
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if addr == "unix:80" {
+					return net.Dial("unix", sock)
+				}
+				return net.Dial(network, addr)
+			},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	table := []struct {
+		Purpose, Method, URL string
+		Code                 int
+		HSTS, Location       string
+	}{
+		{"1. OK: TLS", "GET", "https://127.0.0.1:9007/", 200, "max-age=3600; includeSubDomains", ""},
+		{"2. OK: plain", "GET", "http://127.0.0.1:9008/", 200, "", ""},
+		{"3. OK: unix", "GET", "http://unix/", 200, "", ""},
+		{"4. OK: redirect", "GET", "http://127.0.0.1:9009/a/b?c=1", 301, "", "https://127.0.0.1:9007/a/b?c=1"},
+		{"5. OK: redirect keeps method", "POST", "http://127.0.0.1:9009/a", 308, "", "https://127.0.0.1:9007/a"},
+	}
+	for _, x := range table {
+		req, err := http.NewRequest(x.Method, x.URL, nil)
+		assert.Nil(t, err, x.Purpose)
+		res, err := client.Do(req)
+		assert.Nil(t, err, x.Purpose)
+		err = res.Body.Close()
+		assert.Nil(t, err, x.Purpose)
+		assert.EqualValues(t, x.Code, res.StatusCode, x.Purpose)
+		assert.EqualValues(t, x.HSTS, res.Header.Get("Strict-Transport-Security"), x.Purpose)
+		assert.EqualValues(t, x.Location, res.Header.Get("Location"), x.Purpose)
+	}
+	client.CloseIdleConnections()
+
+	cancel()
+	assert.Nil(t, <-done)
+	for _, uri := range []string{"https://127.0.0.1:9007/", "http://127.0.0.1:9008/", "http://127.0.0.1:9009/"} {
+		_, err := client.Get(uri)
+		assert.NotNil(t, err, uri)
+	}
+}
+
+func TestRunContextListenersFail(t *testing.T) {
+	m := New(&Options{
+		Listeners: []Listener{
+			{Addr: "127.0.0.1:9010"},
+			{Addr: "127.0.0.1:-1"},
+		},
+	})
+	err := m.RunContext(context.Background(), -1)
+	assert.NotNil(t, err)
+
+	// Opened listeners must be closed.
+	l, err := net.Listen("tcp", "127.0.0.1:9010")
+	assert.Nil(t, err)
+	err = l.Close()
+	assert.Nil(t, err)
+}
+
+func TestH2C(t *testing.T) {
+	m := New(&Options{H2C: true})
+	m.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, r.Proto)
+	}))
+	done := make(chan error, 1)
+	go func() {
+		done <- m.ListenAndServe("127.0.0.1:9011")
+	}()
+	<-time.After(100 * time.Millisecond)
+
+	// Prior knowledge.
+	tr := &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}
+	client := &http.Client{Timeout: time.Second, Transport: tr}
+	actual, err := getBody(client, "GET", "http://127.0.0.1:9011/")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "HTTP/2.0", actual)
+	tr.CloseIdleConnections()
+
+	// HTTP/1.1 Upgrade.
+	conn, err := net.Dial("tcp", "127.0.0.1:9011")
+	assert.Nil(t, err)
+	_, err = fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: 127.0.0.1\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQAAP__\r\n\r\n")
+	assert.Nil(t, err)
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.EqualValues(t, "h2c", res.Header.Get("Upgrade"))
+	err = conn.Close()
+	assert.Nil(t, err)
+
+	// HTTP/1.1 still works.
+	client = &http.Client{Timeout: time.Second, Transport: &http.Transport{}}
+	actual, err = getBody(client, "GET", "http://127.0.0.1:9011/")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "HTTP/1.1", actual)
+	client.CloseIdleConnections()
+
+	err = m.Shutdown(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, <-done)
+}
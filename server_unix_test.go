@@ -0,0 +1,89 @@
+//go:build unix
+
+package srest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenAndServeUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "srest")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+	sock := dir + "/srest.sock"
+
+	// Stale socket file must be removed.
+	l, err := net.Listen("unix", sock)
+	assert.Nil(t, err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	err = l.Close()
+	assert.Nil(t, err)
+
+	m := New(nil)
+	m.Get("/", say("unix"))
+	done := make(chan error, 1)
+	go func() {
+		done <- m.ListenAndServe("unix:" + sock)
+	}()
+	<-time.After(100 * time.Millisecond)
+
+	client := &http.Client{
+		Timeout: 100 * time.Millisecond,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", sock)
+			},
+		},
+	}
+	actual, err := getBody(client, "GET", "http://unix/")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "unix", actual)
+
+	err = m.Shutdown(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, <-done)
+}
+
+func TestListenUnixInUse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "srest")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+	sock := dir + "/srest.sock"
+
+	l, err := listen("unix:" + sock)
+	assert.Nil(t, err)
+
+	// A live socket must not be taken over.
+	l2, err := listen("unix:" + sock)
+	assert.Nil(t, l2)
+	assert.True(t, errors.Is(err, errAddrInUse), fmt.Sprintf("%v", err))
+
+	// The first listener keeps serving.
+	c, err := net.Dial("unix", sock)
+	assert.Nil(t, err)
+	err = c.Close()
+	assert.Nil(t, err)
+
+	err = l.Close()
+	assert.Nil(t, err)
+	l, err = listen("unix:" + sock)
+	assert.Nil(t, err)
+	err = l.Close()
+	assert.Nil(t, err)
+}
@@ -0,0 +1,167 @@
+package srest
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// sources are the struct tags BindRequest reads from other parts of the
+// request than the body, in the order they are decoded.
+var sources = []struct {
+	tag    string
+	values func(r *http.Request, keys []string) url.Values
+}{
+	{"query", func(r *http.Request, keys []string) url.Values {
+		query, _ := splitQuery(r.URL.Query())
+		return pick(query, keys)
+	}},
+	{"header", func(r *http.Request, keys []string) url.Values {
+		v := url.Values{}
+		for _, k := range keys {
+			if x := r.Header.Values(k); len(x) > 0 {
+				v[k] = x
+			}
+		}
+		return v
+	}},
+	{"cookie", func(r *http.Request, keys []string) url.Values {
+		v := url.Values{}
+		for _, k := range keys {
+			if c, err := r.Cookie(k); err == nil {
+				v.Set(k, c.Value)
+			}
+		}
+		return v
+	}},
+	{"path", func(r *http.Request, keys []string) url.Values {
+		_, params := splitQuery(r.URL.Query())
+		return pick(params, keys)
+	}},
+}
+
+// tagKeys caches the keys declared by a source tag in a type.
+var tagKeys sync.Map
+
+// decodeSources decodes the values declared by query, header, cookie and
+// path tags of dst.
+func (b *Binder) decodeSources(r *http.Request, dst interface{}, errs *ValidationErrors) error {
+	t := reflect.TypeOf(dst)
+	for _, s := range sources {
+		keys := declaredKeys(t, s.tag)
+		if len(keys) < 1 {
+			continue
+		}
+		if err := fieldErrors(errs, b.sources[s.tag].Decode(dst, s.values(r, keys))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// declaredKeys returns the names in tag of t fields, including embedded
+// structs.
+func declaredKeys(t reflect.Type, tag string) []string {
+	type key struct {
+		t   reflect.Type
+		tag string
+	}
+	if v, ok := tagKeys.Load(key{t, tag}); ok {
+		return v.([]string)
+	}
+	var keys []string
+	for _, f := range sourceFields(t) {
+		if name := strings.Split(f.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	tagKeys.Store(key{t, tag}, keys)
+	return keys
+}
+
+// sourceFields returns the exported fields of struct t, the fields of
+// exported embedded structs are promoted with Index relative to t.
+func sourceFields(t reflect.Type) []reflect.StructField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var res []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		if sf.Anonymous && indirectType(sf.Type).Kind() == reflect.Struct {
+			for _, x := range sourceFields(sf.Type) {
+				x.Index = append([]int{i}, x.Index...)
+				res = append(res, x)
+			}
+			continue
+		}
+		res = append(res, sf)
+	}
+	return res
+}
+
+// sourceOnly returns the names of fields with a source or file tag and
+// without tag, the schema decoder would match them by name otherwise.
+func sourceOnly(t reflect.Type, tag string) []string {
+	var names []string
+	for _, f := range sourceFields(t) {
+		if _, ok := f.Tag.Lookup(tag); ok {
+			continue
+		}
+		if _, ok := f.Tag.Lookup("file"); ok {
+			names = append(names, f.Name)
+			continue
+		}
+		for _, s := range sources {
+			if _, ok := f.Tag.Lookup(s.tag); ok {
+				names = append(names, f.Name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// decodeValues decodes v with the BindRequest decoder, values that match
+// fields bound only from other sources, or their nested fields, are dropped.
+func (b *Binder) decodeValues(dst interface{}, v url.Values) error {
+	names := sourceOnly(reflect.TypeOf(dst), b.options.TagName)
+	res := make(url.Values, len(v))
+	for k := range v {
+		drop := false
+		for _, name := range names {
+			drop = drop || strings.EqualFold(k, name) ||
+				len(k) > len(name) && strings.EqualFold(k[:len(name)+1], name+".")
+		}
+		if !drop {
+			res[k] = v[k]
+		}
+	}
+	return b.req.Decode(dst, res)
+}
+
+// pick returns the values of keys.
+func pick(v url.Values, keys []string) url.Values {
+	res := url.Values{}
+	for _, k := range keys {
+		if x, ok := v[k]; ok {
+			res[k] = x
+		}
+	}
+	return res
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
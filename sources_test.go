@@ -0,0 +1,118 @@
+package srest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Paging struct is embedded into SourceModel.
+type Paging struct {
+	Page int `query:"page"`
+}
+
+// SourceModel struct binds every request source.
+type SourceModel struct {
+	Paging
+	ID      int      `path:"id"`
+	Q       string   `query:"q"`
+	Tenant  string   `header:"X-Tenant,required" json:"-"`
+	Langs   []string `header:"Accept-Language"`
+	Session string   `cookie:"session"`
+	Name    string   `schema:"name" json:"name"`
+}
+
+// IsValid modeler interface
+func (m *SourceModel) IsValid() error {
+	return nil
+}
+
+func TestBindRequestSources(t *testing.T) {
+	table := []struct {
+		Purpose     string
+		Query       string
+		Params      map[string]string
+		Header      map[string][]string
+		Cookie      string
+		ContentType string
+		Body        string
+		Exp         SourceModel
+		ExpErr      string
+	}{
+		{
+			"1. OK: every source",
+			"q=find&page=2&name=query", map[string]string{"id": "7"},
+			map[string][]string{"X-Tenant": {"acme"}, "Accept-Language": {"es", "en"}}, "session=abc",
+			"", "",
+			SourceModel{Paging{2}, 7, "find", "acme", []string{"es", "en"}, "abc", "query"}, "",
+		},
+		{
+			"2. OK: sources are not bound from form values",
+			"tenant=query&id=1", map[string]string{"id": "7"},
+			map[string][]string{"X-Tenant": {"acme"}}, "",
+			"application/x-www-form-urlencoded", "tenant=form&session=form&name=form",
+			SourceModel{ID: 7, Tenant: "acme", Name: "form"}, "",
+		},
+		{
+			"3. OK: json ignored field",
+			"", nil,
+			map[string][]string{"X-Tenant": {"acme"}}, "",
+			"application/json", `{"tenant":"json","name":"json"}`,
+			SourceModel{Tenant: "acme", Name: "json"}, "",
+		},
+		{
+			"4. Fail: conversion and required header",
+			"page=x", map[string]string{"id": "x"},
+			nil, "",
+			"", "",
+			SourceModel{}, "srest: validation failed: page has an invalid value; " +
+				"X-Tenant is required; id has an invalid value",
+		},
+	}
+	for _, x := range table {
+		r := httptest.NewRequest("POST", "/?"+x.Query, strings.NewReader(x.Body))
+		if x.ContentType != "" {
+			r.Header.Set("Content-Type", x.ContentType)
+		}
+		for k, v := range x.Header {
+			r.Header[k] = v
+		}
+		if x.Cookie != "" {
+			r.Header.Set("Cookie", x.Cookie)
+		}
+		registerVars(r, x.Params)
+		var actual SourceModel
+		err := BindRequest(r, &actual)
+		if x.ExpErr == "" {
+			assert.Nil(t, err, x.Purpose)
+		} else {
+			assert.EqualValues(t, x.ExpErr, fmt.Sprintf("%s", err), x.Purpose)
+		}
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+	}
+}
+
+func TestBindRequestRouted(t *testing.T) {
+	m := New(nil)
+	m.Get("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var x struct {
+			ID int    `path:"id" validate:"gt=0"`
+			Q  string `query:"q"`
+		}
+		if err := BindRequest(r, &x); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%d %s\n", x.ID, x.Q)
+	}))
+	ts := httptest.NewServer(m)
+	defer ts.Close()
+
+	actual, err := getBody(http.DefaultClient, "GET", ts.URL+"/users/3?q=x")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "3 x", actual)
+}
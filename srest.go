@@ -1,14 +1,15 @@
 package srest
 
 import (
-	"fmt"
+	"crypto/tls"
 	"log"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
 	"path"
 	"sort"
-	"syscall"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -22,11 +23,136 @@ type RESTfuler interface {
 	Delete(w http.ResponseWriter, r *http.Request)
 }
 
-// Options type.
+const (
+	// DefaultShutdownTimeout is the time in-flight requests have to finish
+	// when Options.ShutdownTimeout is zero.
+	DefaultShutdownTimeout = 10 * time.Second
+
+	// DefaultShutdownDelay is used when Options.ShutdownDelay is zero and
+	// Health is registered, load balancers see the failing readiness
+	// before the listeners close.
+	DefaultShutdownDelay = 5 * time.Second
+
+	// DefaultReadTimeout is used when Options.ReadTimeout is zero.
+	DefaultReadTimeout = 30 * time.Second
+
+	// DefaultReadHeaderTimeout is used when Options.ReadHeaderTimeout is zero.
+	DefaultReadHeaderTimeout = 10 * time.Second
+
+	// DefaultWriteTimeout is used when Options.WriteTimeout is zero.
+	DefaultWriteTimeout = 30 * time.Second
+
+	// DefaultIdleTimeout is used when Options.IdleTimeout is zero.
+	DefaultIdleTimeout = 120 * time.Second
+
+	// DefaultMaxHeaderBytes is used when Options.MaxHeaderBytes is zero.
+	DefaultMaxHeaderBytes = 1 << 20
+
+	// DefaultUpgradeTimeout is used when Options.UpgradeTimeout is zero.
+	DefaultUpgradeTimeout = 30 * time.Second
+)
+
+// Options type. See LoadOptions to read it from a file and environment.
 type Options struct {
+	// Addr is the listen address used by Run and RunContext when port is
+	// zero, e.g. "127.0.0.1:8080".
+	Addr string
+
 	UseTLS  bool
 	TLSCert string
 	TLSKey  string
+
+	// TLSReload reloads the certificate pair on SIGHUP signal, on Unix, and
+	// TLSReloadInterval, when set, checks the files for changes.
+	TLSReload         bool
+	TLSReloadInterval time.Duration
+
+	// ClientCAs are PEM files used to verify client certificates. When set
+	// and ClientAuth is zero tls.RequireAndVerifyClientCert is used.
+	ClientCAs  []string
+	ClientAuth tls.ClientAuthType
+
+	// TLSMinVersion defaults to tls.VersionTLS12. Empty TLSCipherSuites
+	// uses crypto/tls defaults.
+	TLSMinVersion   uint16
+	TLSCipherSuites []uint16
+
+	// DevCert generates a self-signed certificate for localhost and
+	// DevHosts when TLSCert and TLSKey are empty. It's cached in DevCertDir,
+	// default is the user cache dir. See DevCert function.
+	DevCert    bool
+	DevHosts   []string
+	DevCertDir string
+
+	// BulkLimit is the max number of items accepted by bulk endpoints.
+	// Zero means DefaultBulkLimit.
+	BulkLimit int
+
+	// Binder decodes and validates the items of bulk endpoints, its
+	// BodyLimit limits their bodies. Nil means DefaultBinder.
+	Binder *Binder
+
+	// ShutdownTimeout is the deadline for in-flight requests when the
+	// server is stopping. Zero means DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+
+	// ShutdownDelay is the time readiness endpoints report failure before
+	// the server stops accepting connections. See SREST.Health. Zero means
+	// DefaultShutdownDelay when Health is registered, a negative delay
+	// disables it.
+	ShutdownDelay time.Duration
+
+	// http.Server settings. Zero values take the Default* constants, a
+	// negative timeout disables it.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ErrorLog          *log.Logger
+	ConnState         func(net.Conn, http.ConnState)
+
+	// Listeners are served by Run and RunContext besides the port.
+	Listeners []Listener
+
+	// RedirectHTTP is an address, e.g. ":80", that redirects every request
+	// to the first TLS listener.
+	RedirectHTTP string
+
+	// HSTSMaxAge adds the Strict-Transport-Security header to TLS responses
+	// when is greater than zero.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+
+	// H2C serves HTTP/2 without TLS on plain listeners, with prior
+	// knowledge or HTTP/1.1 Upgrade.
+	H2C bool
+
+	// Logger is used by the server and built-in handlers, default is the
+	// package logger, see SetLogger. AccessLog logs every request with
+	// method, route, status and duration, including 404s and redirects of
+	// RedirectHTTP.
+	Logger    Logger
+	AccessLog bool
+
+	// Upgrade enables zero-downtime restarts: SIGUSR2 starts the executable
+	// again passing it the listeners and this process is drained once the
+	// new one is serving. If it isn't within UpgradeTimeout it's killed and
+	// this process keeps serving. Linux and other Unix systems only.
+	Upgrade        bool
+	UpgradeTimeout time.Duration
+
+	// Views are the template dirs loaded when the server starts, see
+	// LoadViews. Debug reloads them on every request.
+	Views string
+	Debug bool
+}
+
+// Listener type declares an address to serve. Addr can be "host:port" or
+// "unix:/path/to.sock".
+type Listener struct {
+	Addr string
+	TLS  bool
 }
 
 // SREST type.
@@ -35,6 +161,28 @@ type SREST struct {
 	Options  *Options
 	Map      map[string]bool
 	handlers []tmpHandler
+
+	server   *http.Server
+	once     sync.Once
+	regOnce  sync.Once
+	regErr   error
+	tlsOnce  sync.Once
+	tlsErr   error
+	certs    atomic.Pointer[certReloader]
+	done     chan struct{}
+	doneOnce sync.Once
+	redirect *http.Server
+	tlsPort  string
+	draining atomic.Bool
+	health   bool
+
+	hooks      []hook
+	onShutdown []func()
+	startOnce  sync.Once
+	startErr   error
+	started    int
+	hooksOnce  sync.Once
+	hooksErr   error
 }
 
 // New returns a new server.
@@ -46,6 +194,7 @@ func New(options *Options) *SREST {
 		Mux:     mux.NewRouter().StrictSlash(false).SkipClean(false),
 		Options: options,
 		Map:     make(map[string]bool),
+		done:    make(chan struct{}),
 	}
 	return m
 }
@@ -101,51 +250,79 @@ func (m *SREST) Del(uri string, hf http.Handler, mws ...func(http.Handler) http.
 // Create : POST	path/
 // Update : PUT		path/:id
 // Remove : DELETE	path/:id
+// If n implements Bulker the bulk endpoints are generated too.
 func (m *SREST) Use(uri string, n RESTfuler, mws ...func(http.Handler) http.Handler) {
 	m.Get(uri+"/:id", http.HandlerFunc(n.One), mws...)
 	m.Get(uri, http.HandlerFunc(n.List), mws...)
 	m.Post(uri, http.HandlerFunc(n.Create), mws...)
 	m.Put(uri+"/:id", http.HandlerFunc(n.Update), mws...)
 	m.Del(uri+"/:id", http.HandlerFunc(n.Delete), mws...)
+
+	b, ok := n.(Bulker)
+	if !ok {
+		return
+	}
+	m.Post(uri+"/_bulk", bulkHandler(m, b, http.StatusCreated, b.BulkCreate), mws...)
+	m.Put(uri+"/_bulk", bulkHandler(m, b, http.StatusOK, b.BulkUpdate), mws...)
+	m.Del(uri+"/_bulk", bulkHandler(m, b, http.StatusOK, b.BulkDelete), mws...)
 }
 
 // registerHandlers sorts and register the handlers on Mux. Erases the map and
-// slice from SREST in order to free memory. It's called once by Run method.
+// slice from SREST in order to free memory. Options.Views are loaded too. It
+// runs once, later calls return the first result.
 func (m *SREST) registerHandlers() error {
-	// Sort handlers.
-	sort.Sort(ByURIDesc(m.handlers))
+	m.regOnce.Do(func() {
+		if m.Options.Views != "" {
+			if err := LoadViews(m.Options.Views, DefaultFuncMap); err != nil {
+				m.regErr = err
+				return
+			}
+		}
+		if m.Options.Debug {
+			Debug(true)
+		}
+
+		// Sort handlers.
+		sort.Sort(ByURIDesc(m.handlers))
+		for i, x := range m.handlers {
+			m.log().Debug("register", "method", x.Method, "route", x.URI)
+			if m.Options.AccessLog {
+				m.handlers[i].Handler = logRoute(x.URI, x.Handler)
+			}
+		}
+
+		// Register pat endpoints.
+		if err := registerHandlers(m.Mux, m.handlers); err != nil {
+			m.regErr = err
+			return
+		}
+		m.Map = nil
+		m.handlers = nil
+	})
+	return m.regErr
+}
 
-	// Register pat endpoints.
-	if err := registerHandlers(m.Mux, m.handlers); err != nil {
-		return err
+// ServeHTTP implements http.Handler so SREST can be used with
+// httptest.NewServer or embedded into other servers. The first call
+// finalizes route registration, endpoints can't be added after it.
+func (m *SREST) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.Options.AccessLog {
+		m.accessLog(http.HandlerFunc(m.serveHTTP)).ServeHTTP(w, r)
+		return
 	}
-	m.Map = nil
-	m.handlers = nil
-	return nil
+	m.serveHTTP(w, r)
 }
 
-// Run starts the server with http.ListenAndServe or http.ListenAndServeTLS
-// returns a channel binded it to SIGTERM and SIGINT signal.
-func (m *SREST) Run(port int) chan os.Signal {
+func (m *SREST) serveHTTP(w http.ResponseWriter, r *http.Request) {
 	if err := m.registerHandlers(); err != nil {
-		panic(fmt.Sprintf("Run : register handlers : err [%s]", err))
+		m.log().Error("register handlers", "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
 	}
-
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		var err error
-		addrs := fmt.Sprintf(":%v", port)
-		if m.Options.UseTLS {
-			err = http.ListenAndServeTLS(addrs, m.Options.TLSCert, m.Options.TLSKey, m.Mux)
-		} else {
-			err = http.ListenAndServe(addrs, m.Mux)
-		}
-		if err != nil {
-			log.Printf("srest : Run : err [%s]", err)
-		}
-	}()
-	return c
+	if r.TLS != nil && m.Options.HSTSMaxAge > 0 {
+		w.Header().Set("Strict-Transport-Security", m.hsts())
+	}
+	m.Mux.ServeHTTP(w, r)
 }
 
 type tmpHandler struct {
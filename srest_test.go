@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -146,3 +147,39 @@ func TestRouterMatch(t *testing.T) {
 	}
 	done <- struct{}{}
 }
+
+func TestServeHTTP(t *testing.T) {
+	m := New(nil)
+	m.Get("/me", say("GET home"))
+	m.Get("/me/:id", say("GET me detail"))
+	m.Use("/v1/api/friends", &API{})
+	ts := httptest.NewServer(m)
+	defer ts.Close()
+
+	table := []struct {
+		Purpose, Method, URL, Exp string
+	}{
+		{"1. OK: get home", "GET", ts.URL + "/me", "GET home"},
+		{"2. OK: get detail", "GET", ts.URL + "/me/2", "GET me detail-%3Aid=2"},
+		{"3. Fail: 404", "GET", ts.URL + "/me/2/name", "404 page not found"},
+	}
+	for _, x := range table {
+		actual, err := getBody(ts.Client(), x.Method, x.URL)
+		assert.Nil(t, err, x.Purpose)
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+	}
+
+	defer func() {
+		err := recover()
+		assert.EqualValues(t, "definition after start: GET /you", err)
+	}()
+	m.Get("/you", say("GET you"))
+}
+
+func TestServeHTTPFail(t *testing.T) {
+	m := New(nil)
+	m.handlers = append(m.handlers, tmpHandler{})
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+}
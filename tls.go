@@ -0,0 +1,169 @@
+package srest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClientCAs error returned when a client CA file has no certificates.
+	ErrClientCAs = errors.New("srest: no certificates found in client CA file")
+)
+
+// ReloadTLS loads again the certificate pair from Options.TLSCert and
+// Options.TLSKey or the development certificate. New connections use the new
+// certificate.
+func (m *SREST) ReloadTLS() error {
+	certs := m.certs.Load()
+	if certs == nil {
+		return errors.New("srest: TLS is not enabled")
+	}
+	return certs.Load()
+}
+
+// ClientCert returns the verified client certificate of a mutual TLS request
+// or nil if the client didn't send one.
+func ClientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) < 1 || len(r.TLS.VerifiedChains[0]) < 1 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// tlsConfig builds the tls.Config for Options. The certificate pair is
+// served through GetCertificate so it can be reloaded.
+func (m *SREST) tlsConfig() (*tls.Config, error) {
+	o := m.Options
+	certs := &certReloader{certFile: o.TLSCert, keyFile: o.TLSKey}
+	if o.DevCert && o.TLSCert == "" && o.TLSKey == "" {
+		var err error
+		certs.certFile, certs.keyFile, err = DevCert(o.DevCertDir, o.DevHosts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := certs.Load(); err != nil {
+		return nil, err
+	}
+	m.certs.Store(certs)
+
+	cfg := &tls.Config{
+		GetCertificate: certs.GetCertificate,
+		MinVersion:     o.TLSMinVersion,
+		CipherSuites:   o.TLSCipherSuites,
+		ClientAuth:     o.ClientAuth,
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if len(o.ClientCAs) > 0 {
+		pool := x509.NewCertPool()
+		for _, name := range o.ClientCAs {
+			b, err := ioutil.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			if !pool.AppendCertsFromPEM(b) {
+				return nil, fmt.Errorf("%s: %s", ErrClientCAs, name)
+			}
+		}
+		cfg.ClientCAs = pool
+		if cfg.ClientAuth == tls.NoClientCert {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return cfg, nil
+}
+
+// watchTLS reloads the certificate pair on SIGHUP when Options.TLSReload is
+// true and every Options.TLSReloadInterval when the files changed. It
+// returns when done is closed.
+func (m *SREST) watchTLS(done chan struct{}) {
+	sig := make(chan os.Signal, 1)
+	if m.Options.TLSReload && reloadSignal != nil {
+		signal.Notify(sig, reloadSignal)
+		defer signal.Stop(sig)
+	}
+	var tick <-chan time.Time
+	if m.Options.TLSReloadInterval > 0 {
+		t := time.NewTicker(m.Options.TLSReloadInterval)
+		defer t.Stop()
+		tick = t.C
+	}
+	certs := m.certs.Load()
+	for {
+		select {
+		case <-done:
+			return
+		case <-sig:
+		case <-tick:
+			if !certs.Changed() {
+				continue
+			}
+		}
+		if err := certs.Load(); err != nil {
+			m.log().Error("reload TLS", "err", err)
+			continue
+		}
+		m.log().Info("reload TLS", "cert", certs.certFile)
+	}
+}
+
+// certReloader keeps the current certificate pair.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// Load reads the certificate pair, on error the previous one is kept.
+func (c *certReloader) Load() error {
+	modTime := c.lastModified()
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.cert = &cert
+	c.modTime = modTime
+	c.mu.Unlock()
+	return nil
+}
+
+// Changed reports if the files were modified after the last Load.
+func (c *certReloader) Changed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastModified().After(c.modTime)
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+func (c *certReloader) lastModified() time.Time {
+	var t time.Time
+	for _, name := range []string{c.certFile, c.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		if fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+	return t
+}
@@ -0,0 +1,246 @@
+package srest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCert is a certificate pair written to disk for TLS tests.
+type testCert struct {
+	Cert     *x509.Certificate
+	Key      *ecdsa.PrivateKey
+	CertFile string
+	KeyFile  string
+}
+
+// mkCert creates a certificate for cn signed by parent or self-signed when
+// parent is nil.
+func mkCert(dir, cn string, isCA bool, parent *testCert) (*testCert, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.Cert, parent.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	c := &testCert{cert, key, dir + "/" + cn + ".pem", dir + "/" + cn + ".key"}
+	if err := mkFile(c.CertFile, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))); err != nil {
+		return nil, err
+	}
+	if err := mkFile(c.KeyFile, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb}))); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// tlsGet returns the server certificate common name and the response body.
+func tlsGet(uri string, client *testCert) (string, string, error) {
+	cfg := &tls.Config{InsecureSkipVerify: true}
+	if client != nil {
+		// Send the certificate even if it's not signed by the server CAs.
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &tls.Certificate{
+				Certificate: [][]byte{client.Cert.Raw},
+				PrivateKey:  client.Key,
+			}, nil
+		}
+	}
+	c := &http.Client{
+		Timeout:   time.Second,
+		Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true},
+	}
+	res, err := c.Get(uri)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", "", err
+	}
+	if err := res.Body.Close(); err != nil {
+		return "", "", err
+	}
+	return res.TLS.PeerCertificates[0].Subject.CommonName, string(b), nil
+}
+
+func TestTLSReloadAndClientCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "srest")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+
+	ca, err := mkCert(dir, "ca", true, nil)
+	assert.Nil(t, err)
+	client, err := mkCert(dir, "client", false, ca)
+	assert.Nil(t, err)
+	a, err := mkCert(dir, "a", false, nil)
+	assert.Nil(t, err)
+
+	m := New(&Options{
+		UseTLS:            true,
+		TLSCert:           a.CertFile,
+		TLSKey:            a.KeyFile,
+		TLSReloadInterval: 20 * time.Millisecond,
+		ClientCAs:         []string{ca.CertFile},
+		ClientAuth:        tls.VerifyClientCertIfGiven,
+	})
+	m.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := "anonymous"
+		if c := ClientCert(r); c != nil {
+			name = c.Subject.CommonName
+		}
+		_, _ = fmt.Fprint(w, name)
+	}))
+	done := make(chan error, 1)
+	go func() {
+		done <- m.ListenAndServe("127.0.0.1:9005")
+	}()
+	<-time.After(100 * time.Millisecond)
+
+	table := []struct {
+		Purpose    string
+		Client     *testCert
+		Server     string
+		ExpBody    string
+		ExpFailure bool
+	}{
+		{"1. OK: without client cert", nil, "a", "anonymous", false},
+		{"2. OK: with client cert", client, "a", "client", false},
+		{"3. Fail: unknown client cert", a, "", "", true},
+	}
+	for _, x := range table {
+		cn, body, err := tlsGet("https://127.0.0.1:9005/", x.Client)
+		assert.EqualValues(t, x.ExpFailure, err != nil, x.Purpose)
+		assert.EqualValues(t, x.Server, cn, x.Purpose)
+		assert.EqualValues(t, x.ExpBody, body, x.Purpose)
+	}
+
+	// Replace certificate pair files, it must be reloaded.
+	b, err := mkCert(dir, "b", false, nil)
+	assert.Nil(t, err)
+	err = os.Rename(b.CertFile, a.CertFile)
+	assert.Nil(t, err)
+	err = os.Rename(b.KeyFile, a.KeyFile)
+	assert.Nil(t, err)
+	future := time.Now().Add(time.Minute)
+	err = os.Chtimes(a.CertFile, future, future)
+	assert.Nil(t, err)
+	<-time.After(100 * time.Millisecond)
+
+	cn, _, err := tlsGet("https://127.0.0.1:9005/", nil)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "b", cn)
+
+	err = m.ReloadTLS()
+	assert.Nil(t, err)
+
+	err = m.Shutdown(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, <-done)
+}
+
+func TestReloadTLSStartup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "srest")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+	a, err := mkCert(dir, "a", false, nil)
+	assert.Nil(t, err)
+
+	// ReloadTLS can run while the server configures TLS.
+	m := New(&Options{UseTLS: true, TLSCert: a.CertFile, TLSKey: a.KeyFile})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = m.ReloadTLS()
+		}
+	}()
+	err = m.setupTLS()
+	assert.Nil(t, err)
+	<-done
+	err = m.ReloadTLS()
+	assert.Nil(t, err)
+}
+
+func TestTLSFail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "srest")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.RemoveAll(dir)
+		assert.Nil(t, err)
+	}()
+	a, err := mkCert(dir, "a", false, nil)
+	assert.Nil(t, err)
+	err = mkFile(dir+"/empty.pem", "nothing")
+	assert.Nil(t, err)
+
+	err = New(nil).ReloadTLS()
+	assert.EqualValues(t, "srest: TLS is not enabled", fmt.Sprintf("%s", err))
+
+	table := []struct {
+		Purpose string
+		Options *Options
+		Exp     string
+	}{
+		{
+			"1. Fail: cert not found",
+			&Options{UseTLS: true, TLSCert: dir + "/none.pem", TLSKey: a.KeyFile},
+			"open " + dir + "/none.pem: no such file or directory",
+		},
+		{
+			"2. Fail: empty client CA",
+			&Options{UseTLS: true, TLSCert: a.CertFile, TLSKey: a.KeyFile, ClientCAs: []string{dir + "/empty.pem"}},
+			ErrClientCAs.Error() + ": " + dir + "/empty.pem",
+		},
+	}
+	for _, x := range table {
+		err := New(x.Options).ListenAndServe("127.0.0.1:0")
+		assert.EqualValues(t, x.Exp, fmt.Sprintf("%s", err), x.Purpose)
+	}
+}
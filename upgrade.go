@@ -0,0 +1,77 @@
+package srest
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// envListeners is the environment variable with the listeners inherited
+	// from the parent process as "fd:addr" pairs separated by comma.
+	envListeners = "SREST_LISTENERS"
+
+	// envReady is the environment variable with the file descriptor the new
+	// process writes to once it's serving.
+	envReady = "SREST_READY"
+)
+
+// inheritedListeners returns the listeners passed by the parent process
+// indexed by address. The environment variable is removed so it's not
+// passed again.
+func inheritedListeners() (map[string]net.Listener, error) {
+	s := os.Getenv(envListeners)
+	if s == "" {
+		return nil, nil
+	}
+	if err := os.Unsetenv(envListeners); err != nil {
+		return nil, err
+	}
+
+	ls := make(map[string]net.Listener)
+	for _, x := range strings.Split(s, ",") {
+		parts := strings.SplitN(x, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s: invalid value: %s", envListeners, x)
+		}
+		fd, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid fd: %s", envListeners, x)
+		}
+		f := os.NewFile(uintptr(fd), parts[1])
+		l, err := net.FileListener(f)
+		if err != nil {
+			return nil, err
+		}
+		// FileListener duplicates the descriptor.
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		ls[parts[1]] = l
+	}
+	return ls, nil
+}
+
+// notifyReady tells the parent process this one is serving, the parent is
+// drained then. It does nothing when the process wasn't started by upgrade.
+func notifyReady() error {
+	s := os.Getenv(envReady)
+	if s == "" {
+		return nil
+	}
+	if err := os.Unsetenv(envReady); err != nil {
+		return err
+	}
+	fd, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%s: invalid fd: %s", envReady, s)
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	_, err = f.Write([]byte{1})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
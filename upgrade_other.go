@@ -0,0 +1,17 @@
+//go:build !unix
+
+package srest
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"time"
+)
+
+// upgradeSignal is not available on non-Unix systems.
+var upgradeSignal os.Signal
+
+func upgrade(ls []listener, timeout time.Duration) error {
+	return errors.New("srest: upgrade is not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,152 @@
+//go:build unix
+
+package srest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInheritedListeners(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	f, err := l.(*net.TCPListener).File()
+	assert.Nil(t, err)
+	// The descriptor is owned by RunContext.
+	fd, err := syscall.Dup(int(f.Fd()))
+	assert.Nil(t, err)
+	err = f.Close()
+	assert.Nil(t, err)
+	err = l.Close()
+	assert.Nil(t, err)
+
+	err = os.Setenv(envListeners, fmt.Sprintf("%d:127.0.0.1:9012", fd))
+	assert.Nil(t, err)
+
+	m := New(&Options{Listeners: []Listener{{Addr: "127.0.0.1:9012"}}})
+	m.Get("/", say("inherited"))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.RunContext(ctx, -1)
+	}()
+	<-time.After(100 * time.Millisecond)
+	assert.EqualValues(t, "", os.Getenv(envListeners))
+
+	client := &http.Client{Timeout: time.Second, Transport: &http.Transport{}}
+	actual, err := getBody(client, "GET", "http://"+l.Addr().String()+"/")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "inherited", actual)
+	client.CloseIdleConnections()
+
+	cancel()
+	assert.Nil(t, <-done)
+}
+
+func TestInheritedListenersFail(t *testing.T) {
+	table := []struct {
+		Purpose, Env, Exp string
+	}{
+		{"1. Fail: format", "3", "SREST_LISTENERS: invalid value: 3"},
+		{"2. Fail: fd", "x:127.0.0.1:9000", "SREST_LISTENERS: invalid fd: x:127.0.0.1:9000"},
+	}
+	for _, x := range table {
+		err := os.Setenv(envListeners, x.Env)
+		assert.Nil(t, err, x.Purpose)
+		err = New(nil).RunContext(context.Background(), -1)
+		assert.EqualValues(t, x.Exp, fmt.Sprintf("%s", err), x.Purpose)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	err := os.Setenv(envUpgradeChild, "1")
+	assert.Nil(t, err)
+	defer func() {
+		err := os.Unsetenv(envUpgradeChild)
+		assert.Nil(t, err)
+	}()
+
+	m := New(&Options{Upgrade: true})
+	m.Get("/", say("parent"))
+	done := make(chan error, 1)
+	go func() {
+		done <- m.RunContext(context.Background(), 9013)
+	}()
+	<-time.After(100 * time.Millisecond)
+
+	client := &http.Client{Timeout: time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+	actual, err := getBody(client, "GET", "http://localhost:9013/")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "parent", actual)
+
+	// Parent is drained after the child starts.
+	err = syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+	assert.Nil(t, err)
+	assert.Nil(t, <-done)
+
+	actual, err = getBody(client, "GET", "http://localhost:9013/")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "child", actual)
+}
+
+func TestUpgradeFail(t *testing.T) {
+	table := []struct {
+		Purpose string
+		Child   string
+		Timeout time.Duration
+		Exp     string
+	}{
+		{"1. Fail: child exits", "exit", time.Minute, "new process not ready: process exited"},
+		{"2. Fail: child not ready", "hang", 200 * time.Millisecond, "new process not ready: timeout after 200ms"},
+	}
+	for _, x := range table {
+		err := os.Setenv(envUpgradeChild, x.Child)
+		assert.Nil(t, err, x.Purpose)
+
+		var buf logBuffer
+		l := slog.New(slog.NewJSONHandler(&buf, nil))
+		m := New(&Options{Upgrade: true, UpgradeTimeout: x.Timeout, Logger: l})
+		m.Get("/", say("parent"))
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- m.RunContext(ctx, 9016)
+		}()
+		<-time.After(100 * time.Millisecond)
+
+		err = syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+		assert.Nil(t, err, x.Purpose)
+		var actual interface{}
+		for i := 0; i < 100 && actual == nil; i++ {
+			<-time.After(50 * time.Millisecond)
+			entries, err := buf.Entries()
+			assert.Nil(t, err, x.Purpose)
+			for _, e := range entries {
+				if e["msg"] == "upgrade" {
+					actual = e["err"]
+				}
+			}
+		}
+		assert.EqualValues(t, x.Exp, actual, x.Purpose)
+
+		// Parent keeps serving.
+		client := &http.Client{Timeout: time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+		body, err := getBody(client, "GET", "http://localhost:9016/")
+		assert.Nil(t, err, x.Purpose)
+		assert.EqualValues(t, "parent", body, x.Purpose)
+
+		cancel()
+		assert.Nil(t, <-done, x.Purpose)
+		err = os.Unsetenv(envUpgradeChild)
+		assert.Nil(t, err, x.Purpose)
+	}
+}
@@ -0,0 +1,129 @@
+//go:build unix
+
+package srest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// upgradeSignal starts a new process when Options.Upgrade is true.
+var upgradeSignal os.Signal = syscall.SIGUSR2
+
+// upgrade starts the executable again with the same arguments passing it the
+// listeners file descriptors and waits until it's serving, see notifyReady.
+// The new process is killed when it exits or isn't ready within timeout.
+func upgrade(ls []listener, timeout time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	var pairs []string
+	for _, l := range ls {
+		f, err := listenerFile(l)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		// ExtraFiles entry i becomes file descriptor 3+i.
+		pairs = append(pairs, fmt.Sprintf("%d:%s", 2+len(files), l.addr))
+	}
+
+	// The new process writes to the pipe once it's serving.
+	rd, wr, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rd.Close()
+	}()
+	files = append(files, wr)
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		envListeners+"="+strings.Join(pairs, ","),
+		fmt.Sprintf("%s=%d", envReady, 2+len(files)),
+	)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Only the new process holds the write end now, reads return EOF when
+	// it exits.
+	_ = wr.Close()
+	files = files[:len(files)-1]
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := rd.Read(make([]byte, 1))
+		if err == io.EOF {
+			err = errors.New("process exited")
+		}
+		ready <- err
+	}()
+	select {
+	case err = <-ready:
+	case <-time.After(timeout):
+		err = fmt.Errorf("timeout after %s", timeout)
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("new process not ready: %s", err)
+	}
+
+	// Unix socket files belong to the new process now.
+	for _, l := range ls {
+		if ul, ok := l.Listener.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	return nil
+}
+
+// listenerFile duplicates the descriptor of l. Unlike the File method of
+// listeners it keeps l in non-blocking mode, so it can still be closed if
+// the upgrade fails.
+func listenerFile(l listener) (*os.File, error) {
+	sc, ok := l.Listener.(syscall.Conn)
+	if !ok {
+		return nil, fmt.Errorf("listener can't be inherited: %s", l.addr)
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var fd int
+	var derr error
+	if err := rc.Control(func(s uintptr) {
+		syscall.ForkLock.RLock()
+		defer syscall.ForkLock.RUnlock()
+		fd, derr = syscall.Dup(int(s))
+		if derr == nil {
+			syscall.CloseOnExec(fd)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if derr != nil {
+		return nil, derr
+	}
+	return os.NewFile(uintptr(fd), l.addr), nil
+}
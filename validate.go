@@ -0,0 +1,543 @@
+package srest
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// RuleFunc reports if a field satisfies a validation rule. param is the text
+// after "=" in the tag, e.g. "3" for "min=3".
+type RuleFunc func(f Field, param string) bool
+
+// Field type is a struct field being validated. Parent is the struct that
+// contains it, cross-field rules read the other fields from it.
+type Field struct {
+	Name   string
+	Value  reflect.Value
+	Parent reflect.Value
+}
+
+// FieldError type is a field that failed a rule. Field is the path of the
+// field, named by its schema, json or source tag, e.g. "items[1].name".
+// Params are the rule params, oneof has one per value.
+type FieldError struct {
+	Field   string   `json:"field"`
+	Rule    string   `json:"rule"`
+	Params  []string `json:"params,omitempty"`
+	Message string   `json:"message"`
+}
+
+// Error implements error interface.
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors type lists every field that failed decoding or
+// validation.
+type ValidationErrors []*FieldError
+
+// Error implements error interface.
+func (e ValidationErrors) Error() string {
+	s := make([]string, len(e))
+	for i := range e {
+		s[i] = e[i].Message
+	}
+	return "srest: validation failed: " + strings.Join(s, "; ")
+}
+
+// WriteValidationErrors writes errs as JSON with 422 status code:
+//
+//	{"errors": [{"field": "name", "rule": "required", "message": "name is required"}]}
+func WriteValidationErrors(w http.ResponseWriter, errs ValidationErrors) error {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	return json.NewEncoder(w).Encode(struct {
+		Errors ValidationErrors `json:"errors"`
+	}{errs})
+}
+
+// NewFieldError returns the error of field for rule with the message from
+// RegisterMessage. IsValid methods can return it, or ValidationErrors, to
+// have their messages translated, see RegisterCatalog.
+func NewFieldError(field, rule, param string) *FieldError {
+	e := &FieldError{Field: field, Rule: rule}
+	if rule == "oneof" || rule == "mime" {
+		e.Params = strings.Fields(param)
+	} else if param != "" {
+		e.Params = []string{param}
+	}
+	msg, ok := message(DefaultLocale, rule)
+	if !ok {
+		msg = "{field} is invalid"
+	}
+	e.Message = formatMessage(msg, field, param)
+	return e
+}
+
+// formatMessage replaces {field} and {param} in msg.
+func formatMessage(msg, field, param string) string {
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(msg)
+}
+
+var (
+	rules = map[string]RuleFunc{
+		"required":         ruleRequired,
+		"min":              ruleCompare(func(c int) bool { return c >= 0 }),
+		"max":              ruleCompare(func(c int) bool { return c <= 0 }),
+		"len":              ruleCompare(func(c int) bool { return c == 0 }),
+		"eq":               ruleEqual(true),
+		"ne":               ruleEqual(false),
+		"gt":               ruleCompare(func(c int) bool { return c > 0 }),
+		"lt":               ruleCompare(func(c int) bool { return c < 0 }),
+		"oneof":            ruleOneOf,
+		"email":            ruleEmail,
+		"url":              ruleURL,
+		"alpha":            ruleString(unicode.IsLetter),
+		"numeric":          ruleString(unicode.IsDigit),
+		"alphanum":         ruleString(func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }),
+		"eqfield":          ruleField(func(c int) bool { return c == 0 }),
+		"nefield":          ruleField(func(c int) bool { return c != 0 }),
+		"gtfield":          ruleField(func(c int) bool { return c > 0 }),
+		"gtefield":         ruleField(func(c int) bool { return c >= 0 }),
+		"ltfield":          ruleField(func(c int) bool { return c < 0 }),
+		"ltefield":         ruleField(func(c int) bool { return c <= 0 }),
+		"required_with":    ruleRequiredWith(true),
+		"required_without": ruleRequiredWith(false),
+	}
+	rmut sync.RWMutex
+
+	// fieldRules name other fields in their param.
+	fieldRules = map[string]bool{
+		"eqfield": true, "nefield": true, "gtfield": true, "gtefield": true,
+		"ltfield": true, "ltefield": true, "required_with": true, "required_without": true,
+	}
+
+	// messages by rule, {field} and {param} are replaced.
+	messages = map[string]string{
+		"required":         "{field} is required",
+		"min":              "{field} must be at least {param}",
+		"max":              "{field} must be at most {param}",
+		"len":              "{field} must have length {param}",
+		"eq":               "{field} must be {param}",
+		"ne":               "{field} must not be {param}",
+		"gt":               "{field} must be greater than {param}",
+		"lt":               "{field} must be less than {param}",
+		"oneof":            "{field} must be one of {param}",
+		"email":            "{field} must be a valid email address",
+		"url":              "{field} must be a valid URL",
+		"alpha":            "{field} must contain only letters",
+		"numeric":          "{field} must contain only digits",
+		"alphanum":         "{field} must contain only letters and digits",
+		"eqfield":          "{field} must be equal to {param}",
+		"nefield":          "{field} must not be equal to {param}",
+		"gtfield":          "{field} must be greater than {param}",
+		"gtefield":         "{field} must be greater than or equal to {param}",
+		"ltfield":          "{field} must be less than {param}",
+		"ltefield":         "{field} must be less than or equal to {param}",
+		"required_with":    "{field} is required when {param} is present",
+		"required_without": "{field} is required when {param} is missing",
+		"type":             "{field} has an invalid value",
+		"unknown":          "{field} is not allowed",
+		"maxsize":          "{field} must not be larger than {param}",
+		"mime":             "{field} must be of type {param}",
+	}
+	mmut sync.RWMutex
+)
+
+// RegisterRule adds or replaces the validation rule name.
+func RegisterRule(name string, f RuleFunc) {
+	rmut.Lock()
+	defer rmut.Unlock()
+	rules[name] = f
+}
+
+// RegisterMessage sets the DefaultLocale message of rule, "{field}" and
+// "{param}" are replaced by the field path and the rule param, e.g.
+// "{field} must be even". Rules without message use "{field} is invalid".
+func RegisterMessage(rule, msg string) {
+	mmut.Lock()
+	defer mmut.Unlock()
+	messages[rule] = msg
+}
+
+// Validate checks the `validate` tags of the struct v points to, nested
+// structs and slices of structs are validated too. Rules are separated by
+// commas, e.g. `validate:"required,min=3,max=64"`, omitempty skips the
+// rest of them when the field is empty. Built-in rules are:
+//
+//	required, min, max, len, gt, lt		values, or length of strings,
+//						slices and maps
+//	eq, ne					values, strings by value
+//	oneof=a b c				one of space separated values
+//	email, url, alpha, numeric, alphanum	string formats
+//	eqfield, nefield, gtfield, gtefield,	compare with other field
+//	ltfield, ltefield
+//	required_with, required_without		required if other field is or
+//						isn't empty
+//
+// The param of field rules is the Go name of an exported field of the same
+// struct, e.g. `validate:"eqfield=Password"`, other names are returned as
+// error.
+// See RegisterRule to add rules. It returns ValidationErrors with every
+// field that fails, the first failed rule of each field is reported.
+func Validate(v interface{}) error {
+	return validate(v, nil, "schema")
+}
+
+// validate skips the fields in skip, they failed decoding. Field paths use
+// tag names first.
+func validate(v interface{}, skip ValidationErrors, tag string) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	vs := &validator{tag: tag, skip: make(map[string]bool, len(skip))}
+	for _, e := range skip {
+		vs.skip[e.Field] = true
+	}
+	if err := vs.validateStruct(rv, ""); err != nil {
+		return err
+	}
+	if len(vs.errs) > 0 {
+		return vs.errs
+	}
+	return nil
+}
+
+// validator collects the errors of a Validate call.
+type validator struct {
+	tag  string
+	skip map[string]bool
+	errs ValidationErrors
+}
+
+func (vs *validator) validateStruct(rv reflect.Value, prefix string) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		tag := sf.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		f := Field{Name: prefix + fieldName(sf, vs.tag), Value: rv.Field(i), Parent: rv}
+		if vs.skip[f.Name] {
+			continue
+		}
+		if err := vs.validateField(f, tag); err != nil {
+			return err
+		}
+		if err := vs.validateNested(f.Value, f.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNested validates structs inside v.
+func (vs *validator) validateNested(v reflect.Value, name string) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return vs.validateNested(v.Elem(), name)
+	case reflect.Struct:
+		return vs.validateStruct(v, name+".")
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := vs.validateNested(v.Index(i), fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateField adds the first rule of tag that f fails. Unknown rules are
+// returned as error.
+func (vs *validator) validateField(f Field, tag string) error {
+	if tag == "" {
+		return nil
+	}
+	for _, x := range strings.Split(tag, ",") {
+		name, param := x, ""
+		if i := strings.Index(x, "="); i > -1 {
+			name, param = x[:i], x[i+1:]
+		}
+		if name == "omitempty" {
+			if f.Value.IsZero() {
+				return nil
+			}
+			continue
+		}
+		rmut.RLock()
+		rule, ok := rules[name]
+		rmut.RUnlock()
+		if !ok {
+			return fmt.Errorf("srest: unknown validation rule %q on field %s", name, f.Name)
+		}
+		if fieldRules[name] && !exportedField(f.Parent.Type(), param) {
+			return fmt.Errorf("srest: validation rule %q on field %s: %q is not an exported field", name, f.Name, param)
+		}
+		if !rule(f, param) {
+			vs.errs = append(vs.errs, NewFieldError(f.Name, name, param))
+			return nil
+		}
+	}
+	return nil
+}
+
+// fieldName returns the tag, json or source tag name of sf, or its name.
+func fieldName(sf reflect.StructField, tag string) string {
+	for _, key := range []string{tag, "json", "query", "path", "header", "cookie", "file"} {
+		name := strings.Split(sf.Tag.Get(key), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// exportedField reports if name is an exported field of struct t, promoted
+// fields must be reachable through exported embedded structs.
+func exportedField(t reflect.Type, name string) bool {
+	sf, ok := t.FieldByName(name)
+	if !ok {
+		return false
+	}
+	for i := range sf.Index {
+		if t.FieldByIndex(sf.Index[:i+1]).PkgPath != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// hasRules reports if t or its nested structs have `validate` tags.
+func hasRules(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return false
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		if _, ok := sf.Tag.Lookup("validate"); ok || hasRules(sf.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleRequired(f Field, _ string) bool {
+	return !f.Value.IsZero()
+}
+
+// ruleCompare compares the field value, or its length, with param.
+func ruleCompare(ok func(int) bool) RuleFunc {
+	return func(f Field, param string) bool {
+		c, valid := compareParam(indirect(f.Value), param)
+		return valid && ok(c)
+	}
+}
+
+// ruleEqual compares strings by value, other values like ruleCompare.
+func ruleEqual(eq bool) RuleFunc {
+	compare := ruleCompare(func(c int) bool { return (c == 0) == eq })
+	return func(f Field, param string) bool {
+		if s, ok := stringValue(f.Value); ok {
+			return (s == param) == eq
+		}
+		return compare(f, param)
+	}
+}
+
+// ruleField compares the field with the field named by param.
+func ruleField(ok func(int) bool) RuleFunc {
+	return func(f Field, param string) bool {
+		other := f.Parent.FieldByName(param)
+		if !other.IsValid() {
+			return false
+		}
+		c, valid := compareValues(indirect(f.Value), indirect(other))
+		return valid && ok(c)
+	}
+}
+
+// ruleRequiredWith requires the field when the field named by param isn't
+// empty, or when it's empty if with is false.
+func ruleRequiredWith(with bool) RuleFunc {
+	return func(f Field, param string) bool {
+		other := f.Parent.FieldByName(param)
+		if !other.IsValid() {
+			return false
+		}
+		if other.IsZero() == with {
+			return true
+		}
+		return !f.Value.IsZero()
+	}
+}
+
+func ruleOneOf(f Field, param string) bool {
+	s := fmt.Sprint(indirect(f.Value).Interface())
+	for _, x := range strings.Fields(param) {
+		if s == x {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleEmail(f Field, _ string) bool {
+	s, ok := stringValue(f.Value)
+	if !ok {
+		return false
+	}
+	a, err := mail.ParseAddress(s)
+	return err == nil && a.Address == s
+}
+
+func ruleURL(f Field, _ string) bool {
+	s, ok := stringValue(f.Value)
+	if !ok {
+		return false
+	}
+	u, err := url.ParseRequestURI(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// ruleString checks every rune of non-empty strings.
+func ruleString(ok func(rune) bool) RuleFunc {
+	return func(f Field, _ string) bool {
+		s, valid := stringValue(f.Value)
+		if !valid || s == "" {
+			return false
+		}
+		for _, r := range s {
+			if !ok(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func stringValue(v reflect.Value) (string, bool) {
+	v = indirect(v)
+	if v.Kind() != reflect.String {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// indirect returns the value pointed by v, nil pointers are returned as is.
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// compareParam compares v with param: numbers by value and strings, slices,
+// maps and arrays by length. Durations use time.ParseDuration format.
+func compareParam(v reflect.Value, param string) (int, bool) {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(param)
+		if err != nil {
+			return 0, false
+		}
+		return cmp.Compare(v.Int(), int64(d)), true
+	}
+	switch v.Kind() {
+	case reflect.String:
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return 0, false
+		}
+		return cmp.Compare(int64(len([]rune(v.String()))), int64(n)), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return 0, false
+		}
+		return cmp.Compare(int64(v.Len()), int64(n)), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return cmp.Compare(v.Int(), n), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return cmp.Compare(v.Uint(), n), true
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return 0, false
+		}
+		return cmp.Compare(v.Float(), n), true
+	}
+	return 0, false
+}
+
+// compareValues compares two values of the same kind, times are compared
+// by instant.
+func compareValues(a, b reflect.Value) (int, bool) {
+	if !a.CanInterface() || !b.CanInterface() {
+		return 0, false
+	}
+	if ta, ok := a.Interface().(time.Time); ok {
+		tb, ok := b.Interface().(time.Time)
+		if !ok {
+			return 0, false
+		}
+		return ta.Compare(tb), true
+	}
+	if a.Kind() != b.Kind() {
+		return 0, false
+	}
+	switch a.Kind() {
+	case reflect.String:
+		return strings.Compare(a.String(), b.String()), true
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0, true
+		}
+		return 1, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float()), true
+	}
+	return 0, false
+}
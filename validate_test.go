@@ -0,0 +1,284 @@
+package srest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Address struct is nested into Signup.
+type Address struct {
+	City string `validate:"required"`
+}
+
+// Signup struct uses validate tags without Modeler interface.
+type Signup struct {
+	Name     string    `schema:"name" validate:"required,min=3,max=8"`
+	Email    string    `schema:"email" validate:"omitempty,email"`
+	Role     string    `schema:"role" validate:"oneof=admin user"`
+	Age      int       `schema:"age" validate:"gt=17"`
+	Password string    `schema:"password" validate:"required,nefield=Name"`
+	Confirm  string    `schema:"confirm" validate:"eqfield=Password"`
+	Tags     []string  `schema:"tags" validate:"max=2"`
+	Start    time.Time `validate:"-"`
+	Address  *Address
+	Items    []Address
+}
+
+// validSignup returns a Signup without errors.
+func validSignup() Signup {
+	return Signup{
+		Name:     "name",
+		Role:     "user",
+		Age:      18,
+		Password: "secret",
+		Confirm:  "secret",
+		Address:  &Address{City: "x"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	table := []struct {
+		Purpose string
+		Modify  func(s *Signup)
+		Exp     string
+	}{
+		{"1. OK", func(s *Signup) {}, ""},
+		{"2. OK: email", func(s *Signup) { s.Email = "a@b.c" }, ""},
+		{"3. Fail: required", func(s *Signup) { s.Name = "" }, "name is required"},
+		{"4. Fail: min runes", func(s *Signup) { s.Name = "añ" }, "name must be at least 3"},
+		{"5. Fail: max", func(s *Signup) { s.Name = "123456789" }, "name must be at most 8"},
+		{"6. Fail: email", func(s *Signup) { s.Email = "a@" }, "email must be a valid email address"},
+		{"7. Fail: oneof", func(s *Signup) { s.Role = "root" }, "role must be one of admin user"},
+		{"8. Fail: gt", func(s *Signup) { s.Age = 17 }, "age must be greater than 17"},
+		{"9. Fail: nefield", func(s *Signup) { s.Password = "name"; s.Confirm = "name" }, "password must not be equal to Name"},
+		{"10. Fail: eqfield", func(s *Signup) { s.Confirm = "other" }, "confirm must be equal to Password"},
+		{"11. Fail: slice length", func(s *Signup) { s.Tags = []string{"a", "b", "c"} }, "tags must be at most 2"},
+		{"12. Fail: nested pointer", func(s *Signup) { s.Address.City = "" }, "Address.City is required"},
+		{"13. Fail: nested slice", func(s *Signup) { s.Items = []Address{{"x"}, {}} }, "Items[1].City is required"},
+	}
+	for _, x := range table {
+		s := validSignup()
+		x.Modify(&s)
+		err := Validate(&s)
+		if x.Exp == "" {
+			assert.Nil(t, err, x.Purpose)
+			continue
+		}
+		assert.EqualValues(t, "srest: validation failed: "+x.Exp, fmt.Sprintf("%s", err), x.Purpose)
+	}
+
+	// Every failed field is returned.
+	var s Signup
+	s.Tags = []string{"a", "b", "c"}
+	s.Items = []Address{{}}
+	err := Validate(&s)
+	expected := ValidationErrors{
+		{Field: "name", Rule: "required", Message: "name is required"},
+		{Field: "role", Rule: "oneof", Params: []string{"admin", "user"}, Message: "role must be one of admin user"},
+		{Field: "age", Rule: "gt", Params: []string{"17"}, Message: "age must be greater than 17"},
+		{Field: "password", Rule: "required", Message: "password is required"},
+		{Field: "tags", Rule: "max", Params: []string{"2"}, Message: "tags must be at most 2"},
+		{Field: "Items[0].City", Rule: "required", Message: "Items[0].City is required"},
+	}
+	assert.EqualValues(t, expected, err)
+}
+
+func TestValidateEqual(t *testing.T) {
+	type Equal struct {
+		Role  string  `json:"role" validate:"eq=admin"`
+		Name  *string `json:"name" validate:"omitempty,ne=root"`
+		Level int     `json:"level" validate:"ne=0"`
+		Tags  []int   `json:"tags" validate:"eq=2"`
+	}
+	root, guest := "root", "guest"
+	table := []struct {
+		Purpose string
+		Input   Equal
+		Exp     string
+	}{
+		{"1. OK", Equal{Role: "admin", Name: &guest, Level: 1, Tags: []int{1, 2}}, ""},
+		{"2. OK: nil pointer", Equal{Role: "admin", Level: 1, Tags: []int{1, 2}}, ""},
+		{"3. Fail: eq string", Equal{Role: "user", Level: 1, Tags: []int{1, 2}}, "role must be admin"},
+		{"4. Fail: eq string of param length", Equal{Role: "12345", Level: 1, Tags: []int{1, 2}}, "role must be admin"},
+		{"5. Fail: ne string", Equal{Role: "admin", Name: &root, Level: 1, Tags: []int{1, 2}}, "name must not be root"},
+		{"6. Fail: ne number", Equal{Role: "admin", Tags: []int{1, 2}}, "level must not be 0"},
+		{"7. Fail: eq length", Equal{Role: "admin", Level: 1}, "tags must be 2"},
+	}
+	for _, x := range table {
+		err := Validate(&x.Input)
+		if x.Exp == "" {
+			assert.Nil(t, err, x.Purpose)
+			continue
+		}
+		assert.EqualValues(t, "srest: validation failed: "+x.Exp, fmt.Sprintf("%s", err), x.Purpose)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	var x struct {
+		From   time.Time
+		To     time.Time     `validate:"gtfield=From"`
+		Wait   time.Duration `validate:"min=1s"`
+		Code   *string       `validate:"required,len=3,numeric"`
+		Score  float64       `validate:"lt=10.5"`
+		Phone  string
+		Mobile string  `validate:"required_without=Phone"`
+		Site   string  `validate:"omitempty,url"`
+		Ratio  uint    `validate:"max=100"`
+		Ptr    *string `validate:"omitempty,alpha"`
+	}
+	code, name := "123", "abc"
+	x.From = time.Now()
+	x.To = x.From.Add(time.Hour)
+	x.Wait = time.Second
+	x.Code = &code
+	x.Score = 10
+	x.Mobile = "1"
+	x.Site = "https://example.com"
+	x.Ptr = &name
+	err := Validate(&x)
+	assert.Nil(t, err)
+
+	err = Validate(x)
+	assert.Nil(t, err)
+	err = Validate(nil)
+	assert.Nil(t, err)
+	err = Validate("x")
+	assert.Nil(t, err)
+
+	var y struct {
+		Name string `validate:"unknown"`
+	}
+	err = Validate(&y)
+	assert.EqualValues(t, `srest: unknown validation rule "unknown" on field Name`, fmt.Sprintf("%s", err))
+}
+
+// period struct has an unexported field.
+type period struct {
+	start time.Time
+	End   time.Time `schema:"end" validate:"gtfield=start"`
+}
+
+// Period struct embeds an unexported struct.
+type Period struct {
+	period
+	Until time.Time `validate:"gtfield=End"`
+}
+
+func TestValidateFieldParam(t *testing.T) {
+	now := time.Now()
+	table := []struct {
+		Purpose string
+		Input   interface{}
+		Exp     string
+	}{
+		{
+			"1. Fail: unknown field",
+			&struct {
+				Name string `validate:"eqfield=None"`
+			}{},
+			`srest: validation rule "eqfield" on field Name: "None" is not an exported field`,
+		},
+		{
+			"2. Fail: unexported field",
+			&period{start: now, End: now},
+			`srest: validation rule "gtfield" on field end: "start" is not an exported field`,
+		},
+		{
+			"3. Fail: field promoted from unexported struct",
+			&Period{Until: now},
+			`srest: validation rule "gtfield" on field Until: "End" is not an exported field`,
+		},
+	}
+	for _, x := range table {
+		err := Validate(x.Input)
+		assert.EqualValues(t, x.Exp, fmt.Sprintf("%s", err), x.Purpose)
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	RegisterRule("prefix", func(f Field, param string) bool {
+		return strings.HasPrefix(f.Value.String(), param)
+	})
+	var x struct {
+		Name string `validate:"prefix=srest"`
+	}
+	x.Name = "srest rule"
+	err := Validate(&x)
+	assert.Nil(t, err)
+	x.Name = "rule"
+	err = Validate(&x)
+	assert.EqualValues(t, ValidationErrors{{Field: "Name", Rule: "prefix", Params: []string{"srest"}, Message: "Name is invalid"}}, err)
+
+	RegisterMessage("prefix", "{field} must start with {param}")
+	err = Validate(&x)
+	assert.EqualValues(t, "srest: validation failed: Name must start with srest", fmt.Sprintf("%s", err))
+}
+
+func TestBindValidate(t *testing.T) {
+	v := url.Values{}
+	v.Add("name", "name")
+	v.Add("role", "user")
+	v.Add("age", "20")
+	v.Add("password", "secret")
+	v.Add("confirm", "secret")
+	var x Signup
+	err := Bind(v, &x)
+	assert.Nil(t, err)
+
+	// Tags are checked before IsValid.
+	var y struct {
+		Modelfail
+		Name string `schema:"name" validate:"required"`
+	}
+	err = Bind(url.Values{"name": {""}}, &y)
+	assert.EqualValues(t, "srest: validation failed: name is required", fmt.Sprintf("%s", err))
+
+	// Decoding and validation errors are collected, fields that can't be
+	// decoded are not validated.
+	var z Signup
+	err = Bind(url.Values{"name": {"x"}, "age": {"x"}, "other": {"x"}}, &z)
+	expected := ValidationErrors{
+		{Field: "age", Rule: "type", Message: "age has an invalid value"},
+		{Field: "other", Rule: "unknown", Message: "other is not allowed"},
+		{Field: "name", Rule: "min", Params: []string{"3"}, Message: "name must be at least 3"},
+		{Field: "role", Rule: "oneof", Params: []string{"admin", "user"}, Message: "role must be one of admin user"},
+		{Field: "password", Rule: "required", Message: "password is required"},
+	}
+	assert.EqualValues(t, expected, err)
+
+	// Recursive types without tags still need Modeler.
+	type node struct {
+		Next *node
+	}
+	assert.False(t, hasRules(reflect.TypeOf(&node{}), map[reflect.Type]bool{}))
+}
+
+func TestWriteValidationErrors(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"x","age":"20"}`))
+	r.Header.Set("Content-Type", "application/json")
+	var x Signup
+	err := BindJSON(r, &x)
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+
+	w := httptest.NewRecorder()
+	err = WriteValidationErrors(w, errs)
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusUnprocessableEntity, w.Code)
+	assert.EqualValues(t, "application/json; charset=UTF-8", w.Header().Get("Content-Type"))
+	expected := `{"errors":[` +
+		`{"field":"age","rule":"type","message":"age has an invalid value"},` +
+		`{"field":"name","rule":"min","params":["3"],"message":"name must be at least 3"},` +
+		`{"field":"role","rule":"oneof","params":["admin","user"],"message":"role must be one of admin user"},` +
+		`{"field":"password","rule":"required","message":"password is required"}]}` + "\n"
+	assert.EqualValues(t, expected, w.Body.String())
+}
@@ -92,6 +92,7 @@ func Render(w http.ResponseWriter, name string, v interface{}) error {
 		// this generates a race condition. TODO; check later if a really trouble
 		// on debug mode, this is not expected to be turned on into production.
 		if err := LoadViews(templatesDir, DefaultFuncMap); err != nil {
+			pkgLogger().Error("render: load views", "dir", templatesDir, "err", err)
 			return err
 		}
 	}
@@ -99,12 +100,14 @@ func Render(w http.ResponseWriter, name string, v interface{}) error {
 	// Write template to buffer to make sure is working.
 	t, ok := templates[name]
 	if !ok {
+		pkgLogger().Error("render: template not found", "template", name)
 		http.Error(w, "template view not found", http.StatusInternalServerError)
 		return ErrTemplateNotFound
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
 	if err := t.ExecuteTemplate(w, name, v); err != nil {
+		pkgLogger().Error("render", "template", name, "err", err)
 		return err
 	}
 	return nil